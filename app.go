@@ -25,6 +25,8 @@ package fox
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"runtime"
 	"sync"
@@ -34,6 +36,7 @@ import (
 
 	"go.uber.org/automaxprocs/maxprocs"
 
+	"github.com/go-fox/fox/health"
 	"github.com/go-fox/fox/internal/cycle"
 	"github.com/go-fox/fox/internal/signals"
 	"github.com/go-fox/fox/registry"
@@ -60,6 +63,7 @@ type Application struct {
 	serviceInfo   *registry.ServiceInstance // 服务信息
 	maxprocsClean func()                    // max procs clean
 	logger        *slog.Logger              // 日志
+	health        *health.Health            // 健康状态
 }
 
 // New create application
@@ -68,12 +72,16 @@ func New(opts ...Option) *Application {
 	for _, opt := range opts {
 		opt(options)
 	}
+	for name, checker := range options.healthCheckers {
+		options.health.Register(name, checker)
+	}
 	return &Application{
 		options: options,
 		ctx:     options.ctx,
 		cycle:   cycle.NewCycle(),
 		locker:  &sync.RWMutex{},
 		logger:  options.logger,
+		health:  options.health,
 	}
 }
 
@@ -177,7 +185,7 @@ func (app *Application) Run() error {
 // waitSignals 等待退出命令
 func (app *Application) waitSignals() {
 	signals.Shutdown(func(grace bool) {
-		_ = app.Stop()
+		_ = app.stop(grace)
 	})
 }
 
@@ -191,7 +199,7 @@ func (app *Application) startServers() error {
 	app.serviceInfo = info
 	app.locker.Unlock()
 
-	appCtx := NewContext(app.ctx, app)
+	appCtx := health.NewContext(NewContext(app.ctx, app), app.health)
 	wg := sync.WaitGroup{}
 	// 启动前钩子
 	app.runHook(BeforeStart)
@@ -207,13 +215,15 @@ func (app *Application) startServers() error {
 
 	// 注册服务
 	if app.options.registry != nil {
-		ctx, cancel := context.WithTimeout(appCtx, 3*time.Second)
+		ctx, cancel := context.WithTimeout(appCtx, app.options.registrarTimeout)
 		defer cancel()
 		if err = app.options.registry.Register(ctx, info); err != nil {
 			return err
 		}
 	}
 
+	app.health.Resume()
+
 	// 启动后钩子
 	app.runHook(AfterStart)
 
@@ -250,37 +260,147 @@ func (app *Application) buildServerInfo() (*registry.ServiceInstance, error) {
 	return info, nil
 }
 
-// Stop 停止应用
-func (app *Application) Stop() (err error) {
+// Stop 优雅停止应用
+//
+// 停止分为以下阶段:
+//  1. 执行 BeforeStop 钩子并将应用标记为未就绪
+//  2. 从注册中心注销服务
+//  3. 等待 drainDelay，让客户端的 watcher 和负载均衡感知节点下线
+//  4. 按注册顺序依次停止服务，每个服务拥有独立的停止期限，超过期限后服务关闭监听和剩余的连接
+//  5. 执行 AfterStop 钩子
+//
+// 未能在期限内完成排空的服务会汇总在返回的错误中
+func (app *Application) Stop() error {
+	return app.stop(true)
+}
+
+// ForceStop 立即停止应用，跳过排空和服务退出的等待，所有服务以已到期的 ctx 停止并强制关闭连接
+func (app *Application) ForceStop() error {
+	return app.stop(false)
+}
+
+// Ready 应用是否就绪，服务全部启动且依赖检查通过时为 true，开始停止后为 false
+func (app *Application) Ready() bool {
+	return app.health.Ready()
+}
+
+// Health 应用健康状态
+func (app *Application) Health() *health.Health {
+	return app.health
+}
+
+// stop 停止应用
+func (app *Application) stop(grace bool) (err error) {
 	app.stopOnce.Do(func() {
 		// 执行钩子
 		app.runHook(BeforeStop)
-		// 服务信息
-		serverInfo := app.serviceInfo
-		// 注销服务
-		stopCtx, cancel := context.WithTimeout(NewContext(app.ctx, app), app.options.stopTimeout)
+		// 标记未就绪
+		app.health.Shutdown()
+		ctx := health.NewContext(NewContext(app.ctx, app), app.health)
+		stopCtx, cancel := context.WithTimeout(ctx, app.options.stopTimeout)
 		defer cancel()
+		// 注销服务
+		app.locker.RLock()
+		serverInfo := app.serviceInfo
+		app.locker.RUnlock()
 		if app.options.registry != nil && serverInfo != nil {
-			if err = app.options.registry.Deregister(stopCtx, serverInfo); err != nil {
-				app.logger.With(slog.Any("error", err)).Error("stop server error")
+			regCtx, regCancel := context.WithTimeout(stopCtx, app.options.registrarTimeout)
+			if dErr := app.options.registry.Deregister(regCtx, serverInfo); dErr != nil {
+				app.logger.With(slog.Any("error", dErr)).Error("deregister service error")
 			}
+			regCancel()
+		}
+		// 等待下线信息传播
+		if grace {
+			app.drain(stopCtx)
 		}
 		// 停止服务
-		app.locker.RLock()
-		for _, s := range app.options.servers {
-			s := s
-			app.cycle.Run(func() error {
-				return s.Stop(stopCtx)
-			})
+		err = app.stopServers(stopCtx, grace)
+		// 等待服务退出，超过期限仍未退出的服务不再等待，立即停止时不等待
+		if grace {
+			select {
+			case <-app.cycle.Done():
+			case <-stopCtx.Done():
+				app.logger.Warn("servers did not exit before stop timeout, force close")
+			}
 		}
-		app.locker.RUnlock()
-		<-app.cycle.Done()
 		app.runHook(AfterStop)
 		app.cycle.Close()
 	})
 	return err
 }
 
+// drain 等待 drainDelay，期间应用处于未就绪状态
+func (app *Application) drain(ctx context.Context) {
+	if app.options.drainDelay <= 0 {
+		return
+	}
+	app.logger.Info(fmt.Sprintf("draining, wait %s for propagation", app.options.drainDelay))
+	timer := time.NewTimer(app.options.drainDelay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// stopServers 按注册顺序依次停止服务
+func (app *Application) stopServers(ctx context.Context, grace bool) error {
+	app.locker.RLock()
+	servers := app.options.servers
+	app.locker.RUnlock()
+	var errs []error
+	for _, srv := range servers {
+		if err := app.stopServer(ctx, srv, grace); err != nil {
+			app.logger.With(slog.Any("error", err)).Error("stop server error")
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// stopServer 在独立期限内停止单个服务，超过期限视为排空失败
+func (app *Application) stopServer(ctx context.Context, srv transport.Server, grace bool) error {
+	var cancel context.CancelFunc
+	if timeout := app.options.serverStopTimeout; grace && timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+	if !grace {
+		// 立即停止，期限直接到期，服务将强制关闭
+		cancel()
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Stop(ctx)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("server %s failed to drain: %w", serverName(srv), err)
+		}
+		return nil
+	case <-ctx.Done():
+		if !grace {
+			// 立即停止时不等待服务返回
+			return nil
+		}
+		return fmt.Errorf("server %s failed to drain: %w", serverName(srv), ctx.Err())
+	}
+}
+
+// serverName 获取服务描述，用于日志和错误信息
+func serverName(srv transport.Server) string {
+	if e, ok := srv.(transport.Endpointer); ok {
+		if u, err := e.Endpoint(); err == nil {
+			return u.String()
+		}
+	}
+	return fmt.Sprintf("%T", srv)
+}
+
 // clear 清除
 func (app *Application) clear() {
 	app.maxprocsClean()
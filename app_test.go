@@ -0,0 +1,173 @@
+// Package fox
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package fox
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-fox/fox/health"
+)
+
+func TestHealthCheckOption(t *testing.T) {
+	h := health.New()
+	checker := health.CheckerFunc(func(ctx context.Context) error {
+		return errors.New("unavailable")
+	})
+	// HealthCheck 在 Health 之前设置时也要注册到最终使用的 Health 上
+	app := New(HealthCheck("db", checker), Health(h))
+	if app.Health() != h {
+		t.Fatal("health option not applied")
+	}
+	if results := h.Check(context.Background()); results["db"] == nil {
+		t.Fatalf("check not registered, results = %v", results)
+	}
+}
+
+// testServer Stop 需要 drain 时间排空，ctx 先结束时视为强制关闭
+type testServer struct {
+	drain    time.Duration
+	stopOnce sync.Once
+	done     chan struct{}
+	mu       sync.Mutex
+	stopAt   time.Time
+	forced   bool
+}
+
+func newTestServer(drain time.Duration) *testServer {
+	return &testServer{drain: drain, done: make(chan struct{})}
+}
+
+func (s *testServer) Start(ctx context.Context) error {
+	<-s.done
+	return nil
+}
+
+func (s *testServer) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	s.stopAt = time.Now()
+	s.mu.Unlock()
+	defer s.stopOnce.Do(func() { close(s.done) })
+	timer := time.NewTimer(s.drain)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		s.forced = true
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (s *testServer) state() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stopAt, s.forced
+}
+
+func startTestApp(t *testing.T, opts ...Option) *Application {
+	app := New(opts...)
+	if err := app.startServers(); err != nil {
+		t.Fatal(err)
+	}
+	if !app.Ready() {
+		t.Fatal("app should be ready after start")
+	}
+	return app
+}
+
+func TestStopDrain(t *testing.T) {
+	srv := newTestServer(0)
+	app := startTestApp(t, Server(srv), DrainDelay(100*time.Millisecond), StopTimeout(time.Second))
+	start := time.Now()
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- app.Stop()
+	}()
+	// 排空期间应用未就绪，服务尚未停止
+	time.Sleep(50 * time.Millisecond)
+	if app.Ready() {
+		t.Fatal("app should not be ready while draining")
+	}
+	if stopAt, _ := srv.state(); !stopAt.IsZero() {
+		t.Fatal("server stopped before drain delay")
+	}
+	if err := <-stopped; err != nil {
+		t.Fatal(err)
+	}
+	if stopAt, forced := srv.state(); stopAt.Sub(start) < 100*time.Millisecond || forced {
+		t.Fatalf("server stopped after %v, forced = %v", stopAt.Sub(start), forced)
+	}
+}
+
+func TestStopServerDeadline(t *testing.T) {
+	slow := newTestServer(time.Second)
+	fast := newTestServer(0)
+	app := startTestApp(t, Server(slow, fast), ServerStopTimeout(50*time.Millisecond), StopTimeout(time.Second))
+	start := time.Now()
+	err := app.Stop()
+	elapsed := time.Since(start)
+	// 每个服务拥有独立的期限，慢服务超时不影响后面的服务
+	if err == nil || !strings.Contains(err.Error(), "failed to drain") {
+		t.Fatalf("err = %v, want drain error", err)
+	}
+	if elapsed > 500*time.Millisecond {
+		t.Fatalf("stop took %v", elapsed)
+	}
+	if _, forced := slow.state(); !forced {
+		t.Fatal("slow server should be forced to close after its deadline")
+	}
+	if stopAt, forced := fast.state(); stopAt.IsZero() || forced {
+		t.Fatalf("fast server stopAt = %v, forced = %v", stopAt, forced)
+	}
+}
+
+func TestForceStop(t *testing.T) {
+	srv := newTestServer(time.Second)
+	app := startTestApp(t, Server(srv), DrainDelay(time.Second), StopTimeout(5*time.Second))
+	start := time.Now()
+	if err := app.ForceStop(); err != nil {
+		t.Fatal(err)
+	}
+	// 跳过排空和等待，服务以已到期的 ctx 停止
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Fatalf("force stop took %v", elapsed)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, forced := srv.state(); forced {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("server not forced to close")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -50,3 +50,8 @@ const (
 	DefaultDisableLevel   int    = 1       // DefaultDisableLevel 常量 key 标记: 在封禁账号时，默认封禁的等级
 	DefaultDisableService string = "login" // DefaultDisableService 常量 key 标记，默认的禁用服务
 )
+
+const (
+	DefaultSafeService = "important" // DefaultSafeService 二级认证默认的业务标识
+	SafeValue          = "SAFE"      // SafeValue 二级认证标记值
+)
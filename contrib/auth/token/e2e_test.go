@@ -0,0 +1,159 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	nethttp "net/http"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/go-fox/fox/api/gen/go/protocol"
+	"github.com/go-fox/fox/codec"
+	"github.com/go-fox/fox/codec/proto"
+	"github.com/go-fox/fox/transport/http"
+	foxWebsocket "github.com/go-fox/fox/transport/websocket"
+)
+
+type testServer interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+func startServer(t *testing.T, srv testServer) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		_ = srv.Start(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		_ = srv.Stop(context.Background())
+	})
+}
+
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lis
+}
+
+func TestServerMiddlewareE2E(t *testing.T) {
+	repo := NewMemoryRepository()
+	defer repo.Close()
+	tk := New(WithRepository(repo))
+	tokenValue, err := tk.Login(context.Background(), 1001)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lis := listen(t)
+	srv := http.NewServer(http.Listener(lis), http.Middleware(Server(tk)))
+	srv.Get("/me", func(ctx *http.Context) error {
+		h := ctx.Middleware(func(c context.Context, req interface{}) (interface{}, error) {
+			info, _ := FromContext(c)
+			return info.LoginId, nil
+		})
+		reply, err := h(ctx, nil)
+		if err != nil {
+			return err
+		}
+		return ctx.SendString(reply.(string))
+	})
+	startServer(t, srv)
+
+	get := func(token string) (int, string) {
+		req, _ := nethttp.NewRequest(nethttp.MethodGet, "http://"+lis.Addr().String()+"/me", nil)
+		if token != "" {
+			req.Header.Set(tk.GetTokenName(), token)
+		}
+		resp, err := nethttp.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	if code, body := get(tokenValue); code != nethttp.StatusOK || body != "1001" {
+		t.Fatalf("with token: code = %d, body = %s", code, body)
+	}
+	if code, _ := get(""); code != nethttp.StatusUnauthorized {
+		t.Fatalf("without token: code = %d, want 401", code)
+	}
+	if err = tk.Logout(context.Background(), 1001); err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := get(tokenValue); code != nethttp.StatusUnauthorized {
+		t.Fatalf("after logout: code = %d, want 401", code)
+	}
+}
+
+func TestWebsocketAuthorizationE2E(t *testing.T) {
+	repo := NewMemoryRepository()
+	defer repo.Close()
+	tk := New(WithRepository(repo))
+	tokenValue, err := tk.Login(context.Background(), 1001)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lis := listen(t)
+	srv := foxWebsocket.NewServer(foxWebsocket.Listener(lis), foxWebsocket.Authorization(Authorization(tk)))
+	srv.Handler("me", func(ctx foxWebsocket.Context) error {
+		return ctx.Result(wrapperspb.String(ctx.Session().Load(SessionLoginIdKey)))
+	})
+	startServer(t, srv)
+	endpoint := "ws://" + lis.Addr().String()
+	c := codec.GetCodec(proto.Name)
+
+	header := nethttp.Header{}
+	header.Set(tk.GetTokenName(), tokenValue)
+	conn, _, err := websocket.DefaultDialer.Dial(endpoint, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	data, _ := c.Marshal(&protocol.Request{Id: "1", Operation: "me"})
+	if err = conn.WriteMessage(websocket.BinaryMessage, data); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	_, message, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply := &protocol.Reply{}
+	if err = c.Unmarshal(message, reply); err != nil {
+		t.Fatal(err)
+	}
+	loginId := &wrapperspb.StringValue{}
+	if err = c.Unmarshal(reply.Data, loginId); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Id != "1" || loginId.Value != "1001" {
+		t.Fatalf("reply = %+v, login id = %s", reply, loginId.Value)
+	}
+
+	// 没有 token 时握手后立即断开
+	anonymous, _, err := websocket.DefaultDialer.Dial(endpoint, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer anonymous.Close()
+	_ = anonymous.SetReadDeadline(time.Now().Add(3 * time.Second))
+	if _, _, err = anonymous.ReadMessage(); err == nil {
+		t.Fatal("anonymous session should be closed")
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		t.Fatal("anonymous session not closed before deadline")
+	}
+}
@@ -91,3 +91,140 @@ func NewNotLoginError(
 		token:     tk,
 	}
 }
+
+// NotPermissionError 没有权限错误
+type NotPermissionError struct {
+	loginType  string
+	permission string
+}
+
+// Error 实现错误接口
+//
+//	@receiver e
+//	@return string
+func (e NotPermissionError) Error() string {
+	return "无此权限: " + e.permission
+}
+
+// GetLoginType 获取登录类型
+//
+//	@receiver e
+//	@return string
+func (e NotPermissionError) GetLoginType() string {
+	return e.loginType
+}
+
+// GetPermission 获取缺少的权限码
+//
+//	@receiver e
+//	@return string
+func (e NotPermissionError) GetPermission() string {
+	return e.permission
+}
+
+// NewNotPermissionError 构建一个没有权限错误
+//
+//	@param loginType string 登录类型
+//	@param permission string 缺少的权限码
+//	@return *NotPermissionError
+func NewNotPermissionError(loginType string, permission string) *NotPermissionError {
+	return &NotPermissionError{
+		loginType:  loginType,
+		permission: permission,
+	}
+}
+
+// NotRoleError 没有角色错误
+type NotRoleError struct {
+	loginType string
+	role      string
+}
+
+// Error 实现错误接口
+//
+//	@receiver e
+//	@return string
+func (e NotRoleError) Error() string {
+	return "无此角色: " + e.role
+}
+
+// GetLoginType 获取登录类型
+//
+//	@receiver e
+//	@return string
+func (e NotRoleError) GetLoginType() string {
+	return e.loginType
+}
+
+// GetRole 获取缺少的角色
+//
+//	@receiver e
+//	@return string
+func (e NotRoleError) GetRole() string {
+	return e.role
+}
+
+// NewNotRoleError 构建一个没有角色错误
+//
+//	@param loginType string 登录类型
+//	@param role string 缺少的角色
+//	@return *NotRoleError
+func NewNotRoleError(loginType string, role string) *NotRoleError {
+	return &NotRoleError{
+		loginType: loginType,
+		role:      role,
+	}
+}
+
+// NotSafeError 未通过二级认证错误
+type NotSafeError struct {
+	loginType  string
+	tokenValue string
+	service    string
+}
+
+// Error 实现错误接口
+//
+//	@receiver e
+//	@return string
+func (e NotSafeError) Error() string {
+	return "二级认证校验未通过: " + e.service
+}
+
+// GetLoginType 获取登录类型
+//
+//	@receiver e
+//	@return string
+func (e NotSafeError) GetLoginType() string {
+	return e.loginType
+}
+
+// GetTokenValue 获取token值
+//
+//	@receiver e
+//	@return string
+func (e NotSafeError) GetTokenValue() string {
+	return e.tokenValue
+}
+
+// GetService 获取业务标识
+//
+//	@receiver e
+//	@return string
+func (e NotSafeError) GetService() string {
+	return e.service
+}
+
+// NewNotSafeError 构建一个未通过二级认证错误
+//
+//	@param loginType string 登录类型
+//	@param tokenValue string token值
+//	@param service string 业务标识
+//	@return *NotSafeError
+func NewNotSafeError(loginType string, tokenValue string, service string) *NotSafeError {
+	return &NotSafeError{
+		loginType:  loginType,
+		tokenValue: tokenValue,
+		service:    service,
+	}
+}
@@ -2,18 +2,31 @@ module github.com/go-fox/fox/contrib/auth/token
 
 require (
 	github.com/duke-git/lancet/v2 v2.3.4
+	github.com/fasthttp/websocket v1.5.12
 	github.com/go-fox/fox v0.0.0-20250210153006-90b39c7c7809
 	github.com/go-fox/sugar v0.0.0-20241003034413-d0ef6605084f
 	github.com/google/uuid v1.6.0
+	google.golang.org/protobuf v1.36.5
 )
 
 require (
 	dario.cat/mergo v1.0.1 // indirect
 	github.com/BurntSushi/toml v1.4.0 // indirect
+	github.com/andybalholm/brotli v1.1.1 // indirect
+	github.com/go-playground/form/v4 v4.2.1 // indirect
+	github.com/klauspost/compress v1.17.11 // indirect
 	github.com/kr/text v0.1.0 // indirect
+	github.com/panjf2000/ants/v2 v2.11.1 // indirect
+	github.com/savsgio/gotils v0.0.0-20240704082632-aef3928b8a38 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	github.com/valyala/fasthttp v1.58.0 // indirect
 	golang.org/x/exp v0.0.0-20250207012021-f9890c6ad9f3 // indirect
+	golang.org/x/net v0.34.0 // indirect
+	golang.org/x/sync v0.11.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
 	golang.org/x/text v0.22.0 // indirect
-	google.golang.org/protobuf v1.36.5 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250207221924-e9438ea467c6 // indirect
+	google.golang.org/grpc v1.70.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
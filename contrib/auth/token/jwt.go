@@ -0,0 +1,300 @@
+// Package token
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JWTMode jwt 模式
+type JWTMode string
+
+const (
+	JWTModeStateless JWTMode = "stateless" // JWTModeStateless 无状态模式，校验 token 不访问 Repository，不支持注销、顶人下线、封禁和 session
+	JWTModeMixin     JWTMode = "mixin"     // JWTModeMixin 混合模式，登录数据仍保存在 Repository 中，注销和顶人下线的 token 写入黑名单
+)
+
+// JWTAlgorithm jwt 签名算法
+type JWTAlgorithm string
+
+const (
+	JWTAlgorithmHS256 JWTAlgorithm = "HS256" // JWTAlgorithmHS256 HMAC-SHA256
+	JWTAlgorithmRS256 JWTAlgorithm = "RS256" // JWTAlgorithmRS256 RSASSA-PKCS1-v1_5-SHA256
+	JWTAlgorithmEdDSA JWTAlgorithm = "EdDSA" // JWTAlgorithmEdDSA Ed25519
+)
+
+var (
+	// ErrStateless 无状态 jwt 不支持该操作
+	ErrStateless = errors.New("token: stateless jwt does not support this operation")
+	// errJWTInvalid jwt 格式或签名错误
+	errJWTInvalid = errors.New("token: invalid jwt")
+	// errJWTExpired jwt 已过期
+	errJWTExpired = errors.New("token: jwt expired")
+)
+
+// JWTKey jwt 密钥，多个密钥通过 kid 区分，用于密钥轮换
+type JWTKey struct {
+	ID         string           // kid
+	Algorithm  JWTAlgorithm     // 签名算法
+	Secret     []byte           // HS256 密钥
+	PrivateKey crypto.Signer    // RS256、EdDSA 私钥，只校验 token 的服务可以为空
+	PublicKey  crypto.PublicKey // RS256、EdDSA 公钥，为空时从私钥中获取
+}
+
+// NewHS256Key 创建 HS256 密钥
+//
+//	@param id string kid
+//	@param secret []byte 密钥
+//	@return *JWTKey
+func NewHS256Key(id string, secret []byte) *JWTKey {
+	return &JWTKey{ID: id, Algorithm: JWTAlgorithmHS256, Secret: secret}
+}
+
+// NewRS256Key 创建 RS256 密钥，只校验 token 时 privateKey 可以为 nil
+//
+//	@param id string kid
+//	@param privateKey *rsa.PrivateKey 私钥
+//	@param publicKey *rsa.PublicKey 公钥
+//	@return *JWTKey
+func NewRS256Key(id string, privateKey *rsa.PrivateKey, publicKey *rsa.PublicKey) *JWTKey {
+	k := &JWTKey{ID: id, Algorithm: JWTAlgorithmRS256}
+	if privateKey != nil {
+		k.PrivateKey = privateKey
+	}
+	if publicKey != nil {
+		k.PublicKey = publicKey
+	}
+	return k
+}
+
+// NewEdDSAKey 创建 EdDSA 密钥，只校验 token 时 privateKey 可以为 nil
+//
+//	@param id string kid
+//	@param privateKey ed25519.PrivateKey 私钥
+//	@param publicKey ed25519.PublicKey 公钥
+//	@return *JWTKey
+func NewEdDSAKey(id string, privateKey ed25519.PrivateKey, publicKey ed25519.PublicKey) *JWTKey {
+	k := &JWTKey{ID: id, Algorithm: JWTAlgorithmEdDSA}
+	if privateKey != nil {
+		k.PrivateKey = privateKey
+	}
+	if publicKey != nil {
+		k.PublicKey = publicKey
+	}
+	return k
+}
+
+// canSign 是否可以用于签名
+func (k *JWTKey) canSign() bool {
+	if k.Algorithm == JWTAlgorithmHS256 {
+		return len(k.Secret) > 0
+	}
+	return k.PrivateKey != nil
+}
+
+// sign 签名
+func (k *JWTKey) sign(input []byte) ([]byte, error) {
+	switch k.Algorithm {
+	case JWTAlgorithmHS256:
+		mac := hmac.New(sha256.New, k.Secret)
+		mac.Write(input)
+		return mac.Sum(nil), nil
+	case JWTAlgorithmRS256:
+		digest := sha256.Sum256(input)
+		return k.PrivateKey.Sign(rand.Reader, digest[:], crypto.SHA256)
+	case JWTAlgorithmEdDSA:
+		return k.PrivateKey.Sign(rand.Reader, input, crypto.Hash(0))
+	default:
+		return nil, errors.New("token: unsupported jwt algorithm " + string(k.Algorithm))
+	}
+}
+
+// verify 校验签名
+func (k *JWTKey) verify(input, signature []byte) bool {
+	publicKey := k.PublicKey
+	if publicKey == nil && k.PrivateKey != nil {
+		publicKey = k.PrivateKey.Public()
+	}
+	switch k.Algorithm {
+	case JWTAlgorithmHS256:
+		mac := hmac.New(sha256.New, k.Secret)
+		mac.Write(input)
+		return len(k.Secret) > 0 && hmac.Equal(signature, mac.Sum(nil))
+	case JWTAlgorithmRS256:
+		pub, ok := publicKey.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+		digest := sha256.Sum256(input)
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) == nil
+	case JWTAlgorithmEdDSA:
+		pub, ok := publicKey.(ed25519.PublicKey)
+		if !ok {
+			return false
+		}
+		return ed25519.Verify(pub, input, signature)
+	default:
+		return false
+	}
+}
+
+// Claims jwt 中携带的登录信息
+type Claims struct {
+	ID        string         `json:"jti"`              // token唯一标识
+	Subject   string         `json:"sub"`              // 登录账号id
+	LoginType string         `json:"login_type"`       // 登录类型
+	Device    string         `json:"device,omitempty"` // 登录设备
+	Extra     map[string]any `json:"extra,omitempty"`  // 额外数据
+	IssuedAt  int64          `json:"iat"`              // 签发时间
+	ExpiresAt int64          `json:"exp,omitempty"`    // 过期时间，为 0 表示永不过期
+}
+
+// jwtHeader jwt 头
+type jwtHeader struct {
+	Algorithm JWTAlgorithm `json:"alg"`
+	Type      string       `json:"typ"`
+	KeyID     string       `json:"kid,omitempty"`
+}
+
+// jwtCodec jwt 编解码
+type jwtCodec struct {
+	keys []*JWTKey
+}
+
+// newJWTCodec 创建 jwt 编解码，第一个可以签名的密钥用于签发 token，全部密钥都可用于校验
+func newJWTCodec(keys []*JWTKey) *jwtCodec {
+	return &jwtCodec{keys: keys}
+}
+
+// newClaims 创建登录信息
+func newClaims(loginId, loginType, device string, timeout int64, extra map[string]any) *Claims {
+	now := time.Now()
+	c := &Claims{
+		ID:        uuid.New().String(),
+		Subject:   loginId,
+		LoginType: loginType,
+		Device:    device,
+		Extra:     extra,
+		IssuedAt:  now.Unix(),
+	}
+	if timeout != NeverExpire {
+		c.ExpiresAt = now.Add(time.Duration(timeout) * time.Second).Unix()
+	}
+	return c
+}
+
+// ttl 剩余有效期，永不过期时返回 NeverExpire 秒
+func (c *Claims) ttl() time.Duration {
+	if c.ExpiresAt == 0 {
+		return time.Duration(NeverExpire) * time.Second
+	}
+	return time.Until(time.Unix(c.ExpiresAt, 0))
+}
+
+// encode 签发 jwt
+func (j *jwtCodec) encode(claims *Claims) (string, error) {
+	var key *JWTKey
+	for _, k := range j.keys {
+		if k.canSign() {
+			key = k
+			break
+		}
+	}
+	if key == nil {
+		return "", errors.New("token: no jwt signing key")
+	}
+	header, err := json.Marshal(jwtHeader{Algorithm: key.Algorithm, Type: "JWT", KeyID: key.ID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	signature, err := key.sign([]byte(input))
+	if err != nil {
+		return "", err
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// decode 校验并解析 jwt
+func (j *jwtCodec) decode(tokenValue string) (*Claims, error) {
+	parts := strings.Split(tokenValue, ".")
+	if len(parts) != 3 {
+		return nil, errJWTInvalid
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, errJWTInvalid
+	}
+	key := j.key(header.KeyID, header.Algorithm)
+	if key == nil {
+		return nil, errJWTInvalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !key.verify([]byte(parts[0]+"."+parts[1]), signature) {
+		return nil, errJWTInvalid
+	}
+	claims := &Claims{}
+	if err := decodeSegment(parts[1], claims); err != nil {
+		return nil, errJWTInvalid
+	}
+	if claims.ExpiresAt != 0 && time.Now().Unix() >= claims.ExpiresAt {
+		return claims, errJWTExpired
+	}
+	return claims, nil
+}
+
+// key 根据 kid 查找密钥，算法必须与密钥一致，防止算法替换攻击
+func (j *jwtCodec) key(id string, algorithm JWTAlgorithm) *JWTKey {
+	for _, k := range j.keys {
+		if k.ID == id && k.Algorithm == algorithm {
+			return k
+		}
+	}
+	return nil
+}
+
+// decodeSegment 解码 jwt 片段
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
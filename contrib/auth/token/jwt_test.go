@@ -0,0 +1,96 @@
+package token
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStatelessJWT(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []*JWTKey{
+		NewHS256Key("hs", []byte("secret")),
+		NewRS256Key("rs", rsaKey, nil),
+		NewEdDSAKey("ed", edKey, nil),
+	}
+	ctx := context.Background()
+	for _, key := range keys {
+		t.Run(string(key.Algorithm), func(t *testing.T) {
+			issuer := New(WithStyle(StyleJWT), WithJWTMode(JWTModeStateless), WithJWTKeys(key))
+			tokenValue, err := issuer.Login(ctx, 1001, LoginWithDevice("pc"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 只持有公钥的服务也可以校验
+			verifyKey := key
+			switch key.Algorithm {
+			case JWTAlgorithmRS256:
+				verifyKey = NewRS256Key("rs", nil, &rsaKey.PublicKey)
+			case JWTAlgorithmEdDSA:
+				verifyKey = NewEdDSAKey("ed", nil, edPub)
+			}
+			verifier := New(WithStyle(StyleJWT), WithJWTMode(JWTModeStateless), WithJWTKeys(verifyKey))
+			loginId, err := verifier.GetLoginIdAsString(ctx, tokenValue)
+			if err != nil || loginId != "1001" {
+				t.Fatalf("loginId = %s, err = %v", loginId, err)
+			}
+			if _, err := verifier.GetLoginId(ctx, tokenValue[:len(tokenValue)-2]+"xx"); err == nil {
+				t.Fatal("tampered token should be invalid")
+			}
+			if err := verifier.Logout(ctx, 1001); !errors.Is(err, ErrStateless) {
+				t.Fatalf("err = %v, want ErrStateless", err)
+			}
+		})
+	}
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	oldKey, newKey := NewHS256Key("v1", []byte("old")), NewHS256Key("v2", []byte("new"))
+	old := New(WithStyle(StyleJWT), WithJWTMode(JWTModeStateless), WithJWTKeys(oldKey))
+	tokenValue, err := old.Login(ctx, "fox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated := New(WithStyle(StyleJWT), WithJWTMode(JWTModeStateless), WithJWTKeys(newKey, oldKey))
+	if ok, _ := rotated.IsLogin(ctx, tokenValue); !ok {
+		t.Fatal("token signed by old key should still be valid")
+	}
+	newToken, err := rotated.Login(ctx, "fox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := old.IsLogin(ctx, newToken); ok {
+		t.Fatal("token signed by new key should not be valid for old key set")
+	}
+}
+
+func TestExpiredJWT(t *testing.T) {
+	codec := newJWTCodec([]*JWTKey{NewHS256Key("", []byte("secret"))})
+	claims := newClaims("fox", "login", "", 60, nil)
+	claims.ExpiresAt = claims.IssuedAt - 1
+	tokenValue, err := codec.encode(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := New(WithStyle(StyleJWT), WithJWTMode(JWTModeStateless), WithJWTSecret("secret"))
+	_, err = tk.GetLoginId(context.Background(), tokenValue)
+	var nle *NotLoginError
+	if !errors.As(err, &nle) || nle.GetType() != TimeoutToken {
+		t.Fatalf("err = %v, want timeout", err)
+	}
+	if strings.Count(tokenValue, ".") != 2 {
+		t.Fatalf("unexpected jwt format: %s", tokenValue)
+	}
+}
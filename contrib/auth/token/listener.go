@@ -56,3 +56,48 @@ type Listener interface {
 	//  @param timeout int64 过期时间
 	DoDisable(logoutType string, loginId any, service string, level int, timeout int64)
 }
+
+// RefreshListener 可选的 refresh token 事件监听器，Listener 同时实现此接口时接收 refresh token 事件
+type RefreshListener interface {
+	// DoRefresh 事件发布：xx 账号使用 refresh token 轮换了凭证
+	//
+	//  @param loginType string 登录类型
+	//  @param loginId any 登录用户
+	//  @param tokenValue string 新的访问 token
+	DoRefresh(loginType string, loginId any, tokenValue string)
+
+	// DoRefreshReuse 事件发布：xx 账号的 refresh token 被重复使用，令牌族已被吊销
+	//
+	//  @param loginType string 登录类型
+	//  @param loginId any 登录用户
+	//  @param refreshToken string 被重复使用的 refresh token
+	DoRefreshReuse(loginType string, loginId any, refreshToken string)
+}
+
+// KickOutListener 可选的踢人下线事件监听器，Listener 同时实现此接口时接收踢人下线事件
+type KickOutListener interface {
+	// DoKickOut 事件发布：xx 账号被踢下线
+	//
+	//  @param logoutType string 登录类型
+	//  @param logoutId any 登录用户
+	//  @param tokenValue string token值
+	DoKickOut(logoutType string, logoutId any, tokenValue string)
+}
+
+// SafeListener 可选的二级认证事件监听器，Listener 同时实现此接口时接收二级认证事件
+type SafeListener interface {
+	// DoOpenSafe 事件发布：xx token 开启二级认证
+	//
+	//  @param loginType string 登录类型
+	//  @param tokenValue string token值
+	//  @param service string 业务标识
+	//  @param safeTime int64 二级认证有效期（单位：秒）
+	DoOpenSafe(loginType string, tokenValue string, service string, safeTime int64)
+
+	// DoCloseSafe 事件发布：xx token 关闭二级认证
+	//
+	//  @param loginType string 登录类型
+	//  @param tokenValue string token值
+	//  @param service string 业务标识
+	DoCloseSafe(loginType string, tokenValue string, service string)
+}
@@ -102,6 +102,48 @@ func (l *ListenerManager) DoReplaced(logoutType string, logoutId any, tokenValue
 	}
 }
 
+// DoRefresh 执行轮换凭证钩子
+//
+//	@receiver l
+//	@param loginType string
+//	@param loginId any
+//	@param tokenValue string
+func (l *ListenerManager) DoRefresh(loginType string, loginId any, tokenValue string) {
+	for _, listener := range l.listeners {
+		if ln, ok := listener.(RefreshListener); ok {
+			go ln.DoRefresh(loginType, loginId, tokenValue)
+		}
+	}
+}
+
+// DoRefreshReuse 执行 refresh token 重复使用钩子
+//
+//	@receiver l
+//	@param loginType string
+//	@param loginId any
+//	@param refreshToken string
+func (l *ListenerManager) DoRefreshReuse(loginType string, loginId any, refreshToken string) {
+	for _, listener := range l.listeners {
+		if ln, ok := listener.(RefreshListener); ok {
+			go ln.DoRefreshReuse(loginType, loginId, refreshToken)
+		}
+	}
+}
+
+// DoKickOut 执行被踢下线钩子
+//
+//	@receiver l
+//	@param logoutType string
+//	@param logoutId any
+//	@param tokenValue string
+func (l *ListenerManager) DoKickOut(logoutType string, logoutId any, tokenValue string) {
+	for _, listener := range l.listeners {
+		if ln, ok := listener.(KickOutListener); ok {
+			go ln.DoKickOut(logoutType, logoutId, tokenValue)
+		}
+	}
+}
+
 // DoDisable 执行被封禁钩子
 //
 //	@receiver l
@@ -115,3 +157,32 @@ func (l *ListenerManager) DoDisable(logoutType string, logoutId any, service str
 		listener.DoDisable(logoutType, logoutId, service, level, timeout)
 	}
 }
+
+// DoOpenSafe 执行开启二级认证钩子
+//
+//	@receiver l
+//	@param loginType string
+//	@param tokenValue string
+//	@param service string
+//	@param safeTime int64
+func (l *ListenerManager) DoOpenSafe(loginType string, tokenValue string, service string, safeTime int64) {
+	for _, listener := range l.listeners {
+		if ln, ok := listener.(SafeListener); ok {
+			go ln.DoOpenSafe(loginType, tokenValue, service, safeTime)
+		}
+	}
+}
+
+// DoCloseSafe 执行关闭二级认证钩子
+//
+//	@receiver l
+//	@param loginType string
+//	@param tokenValue string
+//	@param service string
+func (l *ListenerManager) DoCloseSafe(loginType string, tokenValue string, service string) {
+	for _, listener := range l.listeners {
+		if ln, ok := listener.(SafeListener); ok {
+			go ln.DoCloseSafe(loginType, tokenValue, service)
+		}
+	}
+}
@@ -5,7 +5,12 @@ import (
 	"time"
 )
 
-var _ Listener = (*LoggerListener)(nil)
+var (
+	_ Listener        = (*LoggerListener)(nil)
+	_ RefreshListener = (*LoggerListener)(nil)
+	_ KickOutListener = (*LoggerListener)(nil)
+	_ SafeListener    = (*LoggerListener)(nil)
+)
 
 func newLoggerListener(log *slog.Logger) Listener {
 	return &LoggerListener{
@@ -48,6 +53,33 @@ func (l *LoggerListener) DoReplaced(logoutType string, logoutId any, tokenValue
 	l.logger.Infof("账号 %v 被顶下线 (loginType=%s), 会话凭证 token=%s", logoutId, logoutType, tokenValue)
 }
 
+// DoRefresh 事件发布：xx 账号使用 refresh token 轮换了凭证
+//
+//	@param loginType string 登录类型
+//	@param loginId any 登录用户
+//	@param tokenValue string 新的访问 token
+func (l *LoggerListener) DoRefresh(loginType string, loginId any, tokenValue string) {
+	l.logger.Infof("账号 %v 刷新凭证成功 (loginType=%s), 会话凭证 token=%s", loginId, loginType, tokenValue)
+}
+
+// DoRefreshReuse 事件发布：xx 账号的 refresh token 被重复使用，令牌族已被吊销
+//
+//	@param loginType string 登录类型
+//	@param loginId any 登录用户
+//	@param refreshToken string 被重复使用的 refresh token
+func (l *LoggerListener) DoRefreshReuse(loginType string, loginId any, refreshToken string) {
+	l.logger.Warnf("账号 %v 的 refresh token 被重复使用，已吊销所有关联凭证 (loginType=%s), refresh token=%s", loginId, loginType, refreshToken)
+}
+
+// DoKickOut 事件发布：xx 账号被踢下线
+//
+//	@param logoutType string 登录类型
+//	@param logoutId any 登录用户
+//	@param tokenValue string token值
+func (l *LoggerListener) DoKickOut(logoutType string, logoutId any, tokenValue string) {
+	l.logger.Infof("账号 %v 被踢下线 (loginType=%s), 会话凭证 token=%s", logoutId, logoutType, tokenValue)
+}
+
 // DoDisable 事件发布：xx 账号被封禁
 //
 //	@param logoutType string 登录类型
@@ -59,3 +91,23 @@ func (l *LoggerListener) DoDisable(logoutType string, loginId any, service strin
 	t := time.Second * time.Duration(timeout)
 	l.logger.Infof("账号 %v 被封禁 (loginType=%s),  封禁服务：%s 封禁等级：%d 封禁时间：%s", loginId, logoutType, service, level, t.String())
 }
+
+// DoOpenSafe 事件发布：xx token 开启二级认证
+//
+//	@param loginType string 登录类型
+//	@param tokenValue string token值
+//	@param service string 业务标识
+//	@param safeTime int64 二级认证有效期（单位：秒）
+func (l *LoggerListener) DoOpenSafe(loginType string, tokenValue string, service string, safeTime int64) {
+	t := time.Second * time.Duration(safeTime)
+	l.logger.Infof("token 二级认证成功 (loginType=%s), 业务标识：%s 有效期：%s, 会话凭证 token=%s", loginType, service, t.String(), tokenValue)
+}
+
+// DoCloseSafe 事件发布：xx token 关闭二级认证
+//
+//	@param loginType string 登录类型
+//	@param tokenValue string token值
+//	@param service string 业务标识
+func (l *LoggerListener) DoCloseSafe(loginType string, tokenValue string, service string) {
+	l.logger.Infof("token 二级认证关闭 (loginType=%s), 业务标识：%s, 会话凭证 token=%s", loginType, service, tokenValue)
+}
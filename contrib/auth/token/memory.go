@@ -0,0 +1,455 @@
+// Package token
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package token
+
+import (
+	"container/heap"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-fox/fox/codec"
+	"github.com/go-fox/fox/codec/json"
+)
+
+var (
+	_ SearchRepository = (*MemoryRepository)(nil)
+	_ AtomicRepository = (*MemoryRepository)(nil)
+)
+
+// MemoryRepositoryOption 内存存储仓参数
+type MemoryRepositoryOption func(o *memoryOptions)
+
+// memoryOptions 内存存储仓参数
+type memoryOptions struct {
+	cleanupInterval time.Duration // 清理过期数据的间隔
+	codec           codec.Codec   // 序列化方式
+}
+
+// MemoryWithCleanupInterval 设置清理过期数据的间隔，默认1秒，小于等于0时只在访问时惰性删除
+//
+//	@param interval time.Duration
+//	@return MemoryRepositoryOption
+func MemoryWithCleanupInterval(interval time.Duration) MemoryRepositoryOption {
+	return func(o *memoryOptions) {
+		o.cleanupInterval = interval
+	}
+}
+
+// MemoryWithCodec 设置值的序列化方式，默认json
+//
+//	@param c codec.Codec
+//	@return MemoryRepositoryOption
+func MemoryWithCodec(c codec.Codec) MemoryRepositoryOption {
+	return func(o *memoryOptions) {
+		o.codec = c
+	}
+}
+
+// memoryEntry 内存存储的数据
+type memoryEntry struct {
+	key      string
+	value    []byte
+	expireAt time.Time // 过期时间，零值表示永不过期
+	index    int       // 在过期堆中的下标，-1 表示不在堆中
+}
+
+// expired 是否已过期
+//
+//	@receiver e
+//	@param now time.Time
+//	@return bool
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expireAt.IsZero() && !now.Before(e.expireAt)
+}
+
+// expireHeap 按过期时间排序的小顶堆
+type expireHeap []*memoryEntry
+
+func (h expireHeap) Len() int           { return len(h) }
+func (h expireHeap) Less(i, j int) bool { return h[i].expireAt.Before(h[j].expireAt) }
+func (h expireHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expireHeap) Push(x any) {
+	e := x.(*memoryEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *expireHeap) Pop() any {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	e.index = -1
+	*h = old[:n-1]
+	return e
+}
+
+// MemoryRepository 进程内存储仓，适用于单节点部署和单元测试，过期数据使用小顶堆定时清理，并发安全
+type MemoryRepository struct {
+	opts    *memoryOptions
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	expires expireHeap
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewMemoryRepository 创建内存存储仓，使用完毕后需要调用 Close 停止后台清理
+//
+//	@param opts ...MemoryRepositoryOption
+//	@return *MemoryRepository
+func NewMemoryRepository(opts ...MemoryRepositoryOption) *MemoryRepository {
+	o := &memoryOptions{
+		cleanupInterval: time.Second,
+		codec:           codec.GetCodec(json.Name),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	r := &MemoryRepository{
+		opts:    o,
+		entries: make(map[string]*memoryEntry),
+		done:    make(chan struct{}),
+	}
+	if o.cleanupInterval > 0 {
+		go r.janitor()
+	}
+	return r
+}
+
+// Get 获取数据，数据不存在时不返回错误，value 保持不变
+//
+//	@receiver r
+//	@param ctx context.Context
+//	@param key string
+//	@param value any 接收数据的指针
+//	@return error
+func (r *MemoryRepository) Get(ctx context.Context, key string, value any) error {
+	r.mu.Lock()
+	e := r.load(key, time.Now())
+	var data []byte
+	if e != nil {
+		data = e.value
+	}
+	r.mu.Unlock()
+	if data == nil {
+		return nil
+	}
+	return r.opts.codec.Unmarshal(data, value)
+}
+
+// Set 写入数据，timeout 小于等于0时永不过期
+//
+//	@receiver r
+//	@param ctx context.Context
+//	@param key string
+//	@param value any
+//	@param timeout time.Duration 有效期
+//	@return error
+func (r *MemoryRepository) Set(ctx context.Context, key string, value any, timeout time.Duration) error {
+	data, err := r.opts.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var expireAt time.Time
+	if timeout > 0 {
+		expireAt = time.Now().Add(timeout)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.entries[key]
+	if !ok {
+		e = &memoryEntry{key: key, index: -1}
+		r.entries[key] = e
+	}
+	e.value = data
+	r.setExpireAt(e, expireAt)
+	return nil
+}
+
+// SetNX 数据不存在时写入，timeout 小于等于0时永不过期
+//
+//	@receiver r
+//	@param ctx context.Context
+//	@param key string
+//	@param value any
+//	@param timeout time.Duration 有效期
+//	@return bool 是否写入成功
+//	@return error
+func (r *MemoryRepository) SetNX(ctx context.Context, key string, value any, timeout time.Duration) (bool, error) {
+	data, err := r.opts.codec.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	var expireAt time.Time
+	if timeout > 0 {
+		expireAt = now.Add(timeout)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.load(key, now) != nil {
+		return false, nil
+	}
+	e := &memoryEntry{key: key, index: -1, value: data}
+	r.entries[key] = e
+	r.setExpireAt(e, expireAt)
+	return true, nil
+}
+
+// Update 修改数据，保持剩余有效期不变，数据不存在时不做处理
+//
+//	@receiver r
+//	@param ctx context.Context
+//	@param key string
+//	@param value any
+//	@return error
+func (r *MemoryRepository) Update(ctx context.Context, key string, value any) error {
+	data, err := r.opts.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e := r.load(key, time.Now()); e != nil {
+		e.value = data
+	}
+	return nil
+}
+
+// TTL 获取剩余有效期，永不过期时返回 NeverExpire 秒，数据不存在时返回 NotValueExpire 秒
+//
+//	@receiver r
+//	@param ctx context.Context
+//	@param key string
+//	@return time.Duration
+//	@return error
+func (r *MemoryRepository) TTL(ctx context.Context, key string) (time.Duration, error) {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e := r.load(key, now)
+	if e == nil {
+		return time.Duration(NotValueExpire) * time.Second, nil
+	}
+	if e.expireAt.IsZero() {
+		return time.Duration(NeverExpire) * time.Second, nil
+	}
+	return e.expireAt.Sub(now), nil
+}
+
+// UpdateTTL 修改剩余有效期，ttl 为 NeverExpire 秒时改为永不过期，其他小于等于0的值会直接删除数据
+//
+//	@receiver r
+//	@param ctx context.Context
+//	@param key string
+//	@param ttl time.Duration
+//	@return error
+func (r *MemoryRepository) UpdateTTL(ctx context.Context, key string, ttl time.Duration) error {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e := r.load(key, now)
+	if e == nil {
+		return nil
+	}
+	switch {
+	case ttl == time.Duration(NeverExpire)*time.Second:
+		r.setExpireAt(e, time.Time{})
+	case ttl <= 0:
+		r.remove(e)
+	default:
+		r.setExpireAt(e, now.Add(ttl))
+	}
+	return nil
+}
+
+// Delete 删除数据
+//
+//	@receiver r
+//	@param ctx context.Context
+//	@param key string
+//	@return error
+func (r *MemoryRepository) Delete(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, ok := r.entries[key]; ok {
+		r.remove(e)
+	}
+	return nil
+}
+
+// Search 搜索以 prefix 开头并包含 keyword 的未过期 key
+//
+//	@receiver r
+//	@param ctx context.Context
+//	@param prefix string key前缀
+//	@param keyword string 关键字，为空时不过滤
+//	@param start int 开始下标
+//	@param size int 获取数量，-1 代表到末尾
+//	@param sortAsc bool 是否按 key 升序
+//	@return []string
+//	@return error
+func (r *MemoryRepository) Search(ctx context.Context, prefix, keyword string, start, size int, sortAsc bool) ([]string, error) {
+	r.mu.Lock()
+	r.evict(time.Now())
+	keys := make([]string, 0)
+	for key := range r.entries {
+		if strings.HasPrefix(key, prefix) && strings.Contains(key[len(prefix):], keyword) {
+			keys = append(keys, key)
+		}
+	}
+	r.mu.Unlock()
+	if sortAsc {
+		sort.Strings(keys)
+	} else {
+		sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+	}
+	return page(keys, start, size), nil
+}
+
+// Len 当前未过期的数据数量
+//
+//	@receiver r
+//	@return int
+func (r *MemoryRepository) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.evict(time.Now())
+	return len(r.entries)
+}
+
+// Close 停止后台清理
+//
+//	@receiver r
+//	@return error
+func (r *MemoryRepository) Close() error {
+	r.once.Do(func() {
+		close(r.done)
+	})
+	return nil
+}
+
+// janitor 定时清理过期数据
+//
+//	@receiver r
+func (r *MemoryRepository) janitor() {
+	ticker := time.NewTicker(r.opts.cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.done:
+			return
+		case now := <-ticker.C:
+			r.mu.Lock()
+			r.evict(now)
+			r.mu.Unlock()
+		}
+	}
+}
+
+// load 获取未过期的数据，已过期的数据会被删除，调用方需持有锁
+//
+//	@receiver r
+//	@param key string
+//	@param now time.Time
+//	@return *memoryEntry
+func (r *MemoryRepository) load(key string, now time.Time) *memoryEntry {
+	e, ok := r.entries[key]
+	if !ok {
+		return nil
+	}
+	if e.expired(now) {
+		r.remove(e)
+		return nil
+	}
+	return e
+}
+
+// evict 删除所有已过期的数据，调用方需持有锁
+//
+//	@receiver r
+//	@param now time.Time
+func (r *MemoryRepository) evict(now time.Time) {
+	for len(r.expires) > 0 && r.expires[0].expired(now) {
+		r.remove(r.expires[0])
+	}
+}
+
+// setExpireAt 修改过期时间并维护过期堆，调用方需持有锁
+//
+//	@receiver r
+//	@param e *memoryEntry
+//	@param expireAt time.Time 零值表示永不过期
+func (r *MemoryRepository) setExpireAt(e *memoryEntry, expireAt time.Time) {
+	e.expireAt = expireAt
+	switch {
+	case expireAt.IsZero() && e.index >= 0:
+		heap.Remove(&r.expires, e.index)
+	case expireAt.IsZero():
+	case e.index >= 0:
+		heap.Fix(&r.expires, e.index)
+	default:
+		heap.Push(&r.expires, e)
+	}
+}
+
+// remove 删除数据，调用方需持有锁
+//
+//	@receiver r
+//	@param e *memoryEntry
+func (r *MemoryRepository) remove(e *memoryEntry) {
+	if e.index >= 0 {
+		heap.Remove(&r.expires, e.index)
+	}
+	delete(r.entries, e.key)
+}
+
+// page 分页截取
+//
+//	@param keys []string
+//	@param start int 开始下标
+//	@param size int 获取数量，-1 代表到末尾
+//	@return []string
+func page(keys []string, start, size int) []string {
+	if start < 0 {
+		start = 0
+	}
+	if start >= len(keys) {
+		return []string{}
+	}
+	end := len(keys)
+	if size >= 0 && start+size < end {
+		end = start + size
+	}
+	return keys[start:end]
+}
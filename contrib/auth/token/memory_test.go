@@ -0,0 +1,72 @@
+package token
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository(MemoryWithCleanupInterval(10 * time.Millisecond))
+	defer repo.Close()
+
+	if ttl, _ := repo.TTL(ctx, "missing"); ttl != time.Duration(NotValueExpire)*time.Second {
+		t.Fatalf("ttl = %v, want NotValueExpire", ttl)
+	}
+	_ = repo.Set(ctx, "never", "v", time.Duration(NeverExpire)*time.Second)
+	if ttl, _ := repo.TTL(ctx, "never"); ttl != time.Duration(NeverExpire)*time.Second {
+		t.Fatalf("ttl = %v, want NeverExpire", ttl)
+	}
+	_ = repo.Set(ctx, "short", "v", 20*time.Millisecond)
+	_ = repo.Update(ctx, "short", "updated")
+	var value string
+	if err := repo.Get(ctx, "short", &value); err != nil || value != "updated" {
+		t.Fatalf("value = %s, err = %v", value, err)
+	}
+	_ = repo.UpdateTTL(ctx, "never", time.Hour)
+	if ttl, _ := repo.TTL(ctx, "never"); ttl <= 0 || ttl > time.Hour {
+		t.Fatalf("ttl = %v, want about 1h", ttl)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := repo.Len(); n != 1 {
+		t.Fatalf("len = %d, want 1 after expiry", n)
+	}
+	value = ""
+	if err := repo.Get(ctx, "short", &value); err != nil || value != "" {
+		t.Fatalf("expired value = %s, err = %v", value, err)
+	}
+}
+
+func TestMemoryRepositoryLogin(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	defer repo.Close()
+
+	tk := New(WithRepository(repo))
+	tokenValue, err := tk.Login(ctx, 1001, LoginWithDevice("pc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loginId, err := tk.GetLoginIdAsString(ctx, tokenValue); err != nil || loginId != "1001" {
+		t.Fatalf("loginId = %s, err = %v", loginId, err)
+	}
+	if err := tk.Logout(ctx, 1001); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tk.GetLoginId(ctx, tokenValue); err == nil {
+		t.Fatal("token should be invalid after logout")
+	}
+
+	mixin := New(WithRepository(repo), WithStyle(StyleJWT), WithJWTSecret("secret"))
+	tokenValue, err = mixin.Login(ctx, 1002)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mixin.LogoutByTokenValue(ctx, tokenValue); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mixin.GetLoginId(ctx, tokenValue); err == nil {
+		t.Fatal("denied jwt should be invalid")
+	}
+}
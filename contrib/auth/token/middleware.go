@@ -0,0 +1,271 @@
+// Package token
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package token
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/duke-git/lancet/v2/convertor"
+
+	foxErrors "github.com/go-fox/fox/errors"
+	"github.com/go-fox/fox/middleware"
+	"github.com/go-fox/fox/middleware/selector"
+	"github.com/go-fox/fox/transport"
+	"github.com/go-fox/fox/transport/websocket"
+)
+
+const (
+	ReasonTokenMissing    = "TOKEN_MISSING"     // ReasonTokenMissing 请求中没有携带 token
+	ReasonTokenInvalid    = "TOKEN_INVALID"     // ReasonTokenInvalid token 无效
+	ReasonTokenTimeout    = "TOKEN_TIMEOUT"     // ReasonTokenTimeout token 已过期
+	ReasonTokenBeReplaced = "TOKEN_BE_REPLACED" // ReasonTokenBeReplaced token 已被顶下线
+	ReasonTokenKickOut    = "TOKEN_KICK_OUT"    // ReasonTokenKickOut token 已被踢下线
+	ReasonTokenFreeze     = "TOKEN_FREEZE"      // ReasonTokenFreeze token 已被冻结
+)
+
+const (
+	SessionLoginIdKey    = "token-login-id"    // SessionLoginIdKey websocket session 中保存登录账号的 key
+	SessionTokenValueKey = "token-token-value" // SessionTokenValueKey websocket session 中保存 token 值的 key
+)
+
+// reasons token 异常标识对应的错误 reason
+var reasons = map[string]string{
+	InvalidToken: ReasonTokenInvalid,
+	TimeoutToken: ReasonTokenTimeout,
+	BeReplaced:   ReasonTokenBeReplaced,
+	KickOut:      ReasonTokenKickOut,
+	FreezeToken:  ReasonTokenFreeze,
+}
+
+// LoginInfo 当前请求的登录信息
+type LoginInfo struct {
+	LoginId    string  // 登录账号id
+	TokenValue string  // token值
+	Session    Session // 账号的 Account-Session，未开启时为 nil
+}
+
+type loginKey struct{}
+
+// NewContext 将登录信息放入上下文
+//
+//	@param ctx context.Context
+//	@param info *LoginInfo 登录信息
+//	@return context.Context
+func NewContext(ctx context.Context, info *LoginInfo) context.Context {
+	return context.WithValue(ctx, loginKey{}, info)
+}
+
+// FromContext 从上下文中获取登录信息
+//
+//	@param ctx context.Context
+//	@return *LoginInfo 登录信息
+//	@return bool 是否存在
+func FromContext(ctx context.Context) (*LoginInfo, bool) {
+	info, ok := ctx.Value(loginKey{}).(*LoginInfo)
+	return info, ok
+}
+
+// MiddlewareOptions 认证中间件参数
+type MiddlewareOptions struct {
+	tokenPrefix string // token值的前缀，例如 Bearer
+	session     bool   // 是否加载 Account-Session
+}
+
+// MiddlewareOption 认证中间件参数
+type MiddlewareOption func(o *MiddlewareOptions)
+
+// MiddlewareWithTokenPrefix 设置token值的前缀，例如 "Bearer "，读取到的token会去掉该前缀
+//
+//	@param prefix string token前缀
+//	@return MiddlewareOption
+func MiddlewareWithTokenPrefix(prefix string) MiddlewareOption {
+	return func(o *MiddlewareOptions) {
+		o.tokenPrefix = prefix
+	}
+}
+
+// MiddlewareWithSession 设置是否把账号的 Account-Session 放入上下文
+//
+//	@param session bool
+//	@return MiddlewareOption
+func MiddlewareWithSession(session bool) MiddlewareOption {
+	return func(o *MiddlewareOptions) {
+		o.session = session
+	}
+}
+
+// Server 认证中间件，从请求头、cookie或查询参数中读取token，校验通过后把登录信息放入上下文，
+// 需要跳过认证的接口可以配合 selector 使用，例如：
+//
+//	selector.Server(token.Server(t)).Match(token.Whitelist("/api.user.v1.User/Login")).Build()
+//
+//	@param t Authenticator token实例
+//	@param opts ...MiddlewareOption 中间件参数
+//	@return middleware.Middleware
+func Server(t Authenticator, opts ...MiddlewareOption) middleware.Middleware {
+	o := &MiddlewareOptions{session: true}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			info, err := o.check(ctx, t, o.tokenFromTransport(t.GetTokenName(), tr))
+			if err != nil {
+				return nil, err
+			}
+			return handler(NewContext(ctx, info), req)
+		}
+	}
+}
+
+// Authorization websocket 连接认证，从握手请求中读取token，校验通过后把登录账号和token值存入 session
+//
+//	@param t Authenticator token实例
+//	@param opts ...MiddlewareOption 中间件参数
+//	@return websocket.AuthorizationHandler
+func Authorization(t Authenticator, opts ...MiddlewareOption) websocket.AuthorizationHandler {
+	o := &MiddlewareOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(ss *websocket.Session) error {
+		name := t.GetTokenName()
+		tokenValue := o.trimPrefix(ss.Header(name))
+		if len(tokenValue) == 0 {
+			tokenValue = o.trimPrefix(ss.Cookie(name))
+		}
+		if len(tokenValue) == 0 {
+			tokenValue = o.trimPrefix(ss.Query(name))
+		}
+		info, err := o.check(context.Background(), t, tokenValue)
+		if err != nil {
+			return err
+		}
+		ss.Store(SessionLoginIdKey, info.LoginId)
+		ss.Store(SessionTokenValueKey, info.TokenValue)
+		return nil
+	}
+}
+
+// Whitelist 白名单匹配方法，不在白名单中的 operation 才需要认证，以 * 结尾的表示前缀匹配
+//
+//	@param operations ...string 白名单
+//	@return selector.MatchFunc
+func Whitelist(operations ...string) selector.MatchFunc {
+	return func(ctx context.Context, operation string) bool {
+		for _, o := range operations {
+			if prefix, ok := strings.CutSuffix(o, "*"); ok {
+				if strings.HasPrefix(operation, prefix) {
+					return false
+				}
+			} else if o == operation {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// tokenFromTransport 依次从请求头、cookie、查询参数中读取token
+//
+//	@receiver o
+//	@param name string token名称
+//	@param tr transport.Transporter
+//	@return string token值
+func (o *MiddlewareOptions) tokenFromTransport(name string, tr transport.Transporter) string {
+	if tokenValue := o.trimPrefix(tr.RequestHeader().Get(name)); len(tokenValue) > 0 {
+		return tokenValue
+	}
+	if c, ok := tr.(interface{ Cookie(key string) string }); ok {
+		if tokenValue := o.trimPrefix(c.Cookie(name)); len(tokenValue) > 0 {
+			return tokenValue
+		}
+	}
+	if q, ok := tr.(interface{ Query(key string) string }); ok {
+		return o.trimPrefix(q.Query(name))
+	}
+	return ""
+}
+
+// trimPrefix 去掉token前缀
+//
+//	@receiver o
+//	@param value string
+//	@return string
+func (o *MiddlewareOptions) trimPrefix(value string) string {
+	if len(o.tokenPrefix) > 0 {
+		value = strings.TrimPrefix(value, o.tokenPrefix)
+	}
+	return strings.TrimSpace(value)
+}
+
+// check 校验token并获取登录信息
+//
+//	@receiver o
+//	@param ctx context.Context
+//	@param t Authenticator
+//	@param tokenValue string token值
+//	@return *LoginInfo 登录信息
+//	@return error 未登录时返回 errors.Unauthorized
+func (o *MiddlewareOptions) check(ctx context.Context, t Authenticator, tokenValue string) (*LoginInfo, error) {
+	if len(tokenValue) == 0 {
+		return nil, foxErrors.Unauthorized(ReasonTokenMissing, "token 不能为空")
+	}
+	loginId, err := t.GetLoginId(ctx, tokenValue)
+	if err != nil {
+		return nil, unauthorized(err)
+	}
+	info := &LoginInfo{
+		LoginId:    convertor.ToString(loginId),
+		TokenValue: tokenValue,
+	}
+	if o.session {
+		if info.Session, err = t.GetSessionByLoginId(ctx, loginId, false); err != nil && !errors.Is(err, ErrStateless) {
+			return nil, err
+		}
+	}
+	return info, nil
+}
+
+// unauthorized 把 NotLoginError 转换为 errors.Unauthorized，其他错误原样返回
+//
+//	@param err error
+//	@return error
+func unauthorized(err error) error {
+	var nle *NotLoginError
+	if !errors.As(err, &nle) {
+		return err
+	}
+	reason, ok := reasons[nle.GetType()]
+	if !ok {
+		reason = ReasonTokenInvalid
+	}
+	return foxErrors.Unauthorized(reason, nle.message).WithCause(err)
+}
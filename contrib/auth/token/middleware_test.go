@@ -0,0 +1,39 @@
+package token
+
+import (
+	"context"
+	"testing"
+
+	foxErrors "github.com/go-fox/fox/errors"
+)
+
+func TestWhitelist(t *testing.T) {
+	match := Whitelist("/api.user.v1.User/Login", "/api.public.*")
+	tests := map[string]bool{
+		"/api.user.v1.User/Login":   false,
+		"/api.user.v1.User/Info":    true,
+		"/api.public.v1.Public/Get": false,
+	}
+	for operation, want := range tests {
+		if got := match(context.Background(), operation); got != want {
+			t.Errorf("match(%s) = %v, want %v", operation, got, want)
+		}
+	}
+}
+
+func TestUnauthorized(t *testing.T) {
+	err := unauthorized(NewNotLoginError(BeReplaced, "login", BeReplacedMessage, "token"))
+	if !foxErrors.IsUnauthorized(err) {
+		t.Fatalf("err = %v, want unauthorized", err)
+	}
+	if reason := foxErrors.Reason(err); reason != ReasonTokenBeReplaced {
+		t.Fatalf("reason = %s, want %s", reason, ReasonTokenBeReplaced)
+	}
+	o := &MiddlewareOptions{tokenPrefix: "Bearer "}
+	if _, err := o.check(context.Background(), nil, ""); foxErrors.Reason(err) != ReasonTokenMissing {
+		t.Fatalf("err = %v, want missing token", err)
+	}
+	if v := o.trimPrefix("Bearer abc"); v != "abc" {
+		t.Fatalf("trimPrefix = %s, want abc", v)
+	}
+}
@@ -0,0 +1,271 @@
+// Package token
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package token
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrSearchNotSupported 存储仓不支持按前缀搜索
+var ErrSearchNotSupported = errors.New("token: repository does not support search")
+
+// SearchRepository 支持按前缀搜索 key 的存储仓，查询在线 token 和 session 时需要存储仓实现此接口
+type SearchRepository interface {
+	Repository
+	// Search 搜索以 prefix 开头并包含 keyword 的 key
+	//
+	//  @param ctx context.Context
+	//  @param prefix string key前缀
+	//  @param keyword string 关键字，为空时不过滤
+	//  @param start int 开始下标
+	//  @param size int 获取数量，-1 代表到末尾
+	//  @param sortAsc bool 是否按 key 升序
+	//  @return []string 完整的key
+	//  @return error
+	Search(ctx context.Context, prefix, keyword string, start, size int, sortAsc bool) ([]string, error)
+}
+
+// SearchTokenValue 搜索在线的 token 值，不包含已被踢下线、顶下线和冻结的 token
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param keyword string 关键字，为空时不过滤
+//	@param start int 开始下标
+//	@param size int 获取数量，-1 代表到末尾
+//	@param sortAsc bool 是否升序
+//	@return []string token值列表
+//	@return error
+func (t *token) SearchTokenValue(ctx context.Context, keyword string, start, size int, sortAsc bool) ([]string, error) {
+	// 过滤后再分页，保证每页数量准确
+	values, err := t.search(ctx, t.splicingKeyTokenValue(""), keyword, 0, -1, sortAsc)
+	if err != nil {
+		return nil, err
+	}
+	online := make([]string, 0, len(values))
+	for _, tokenValue := range values {
+		loginId, err := t.getLoginIdNotHandle(ctx, tokenValue)
+		if err != nil {
+			return nil, err
+		}
+		if t.isValidLoginId(loginId) {
+			online = append(online, tokenValue)
+		}
+	}
+	return page(online, start, size), nil
+}
+
+// SearchSessionId 搜索 Account-Session 的 id
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param keyword string 关键字，为空时不过滤
+//	@param start int 开始下标
+//	@param size int 获取数量，-1 代表到末尾
+//	@param sortAsc bool 是否升序
+//	@return []string 登录账号id列表
+//	@return error
+func (t *token) SearchSessionId(ctx context.Context, keyword string, start, size int, sortAsc bool) ([]string, error) {
+	return t.search(ctx, t.splicingKeySession(""), keyword, start, size, sortAsc)
+}
+
+// SearchTokenSessionId 搜索 Token-Session 的 id
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param keyword string 关键字，为空时不过滤
+//	@param start int 开始下标
+//	@param size int 获取数量，-1 代表到末尾
+//	@param sortAsc bool 是否升序
+//	@return []string token值列表
+//	@return error
+func (t *token) SearchTokenSessionId(ctx context.Context, keyword string, start, size int, sortAsc bool) ([]string, error) {
+	return t.search(ctx, t.splicingKeyTokenSession(""), keyword, start, size, sortAsc)
+}
+
+// GetSignList 获取账号在指定设备上的登录签名，device 为空时返回所有设备
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param loginId any 登录账号id
+//	@param device string 设备类型
+//	@return SignList 签名列表
+//	@return error
+func (t *token) GetSignList(ctx context.Context, loginId any, device string) (SignList, error) {
+	if t.isStateless() {
+		return nil, ErrStateless
+	}
+	ss, err := t.getSessionByLoginId(ctx, loginId, false)
+	if err != nil || ss == nil {
+		return nil, err
+	}
+	return ss.getTokenSignListByDevice(device), nil
+}
+
+// GetTokenTimeout 获取 token 剩余有效期（单位：秒），永不过期返回 NeverExpire，不存在返回 NotValueExpire
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param tokenValue string token值
+//	@return int64
+//	@return error
+func (t *token) GetTokenTimeout(ctx context.Context, tokenValue string) (int64, error) {
+	if len(tokenValue) == 0 {
+		return NotValueExpire, nil
+	}
+	if t.isJWT() {
+		claims, err := t.jwt.decode(tokenValue)
+		if err != nil {
+			return NotValueExpire, nil
+		}
+		return int64(claims.ttl() / time.Second), nil
+	}
+	ttl, err := t.config.repository.TTL(ctx, t.splicingKeyTokenValue(tokenValue))
+	if err != nil {
+		return NotValueExpire, err
+	}
+	return int64(ttl.Seconds()), nil
+}
+
+// KickOut 踢人下线，根据账号id 和 设备类型，被踢下线的 token 访问时返回 KickOut 标记
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param loginId any 登录账号id
+//	@param device string 设备类型，为空时踢下所有设备
+//	@return error
+func (t *token) KickOut(ctx context.Context, loginId any, device string) error {
+	if t.isStateless() {
+		return ErrStateless
+	}
+	ss, err := t.getSessionByLoginId(ctx, loginId, false)
+	if err != nil || ss == nil {
+		return err
+	}
+	for _, sign := range ss.getTokenSignListByDevice(device) {
+		if err := t.offline(ctx, ss, sign.Value, KickOut); err != nil {
+			return err
+		}
+		t.config.listener.DoKickOut(t.config.LoginType, loginId, sign.Value)
+	}
+	// 签名已清空时注销 Account-Session
+	return ss.logoutByTokenSignCountIsZero(ctx)
+}
+
+// KickOutByTokenValue 踢人下线，根据 token 值
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param tokenValue string token值
+//	@return error
+func (t *token) KickOutByTokenValue(ctx context.Context, tokenValue string) error {
+	if t.isStateless() {
+		return ErrStateless
+	}
+	if len(tokenValue) == 0 {
+		return nil
+	}
+	loginId, err := t.getLoginIdNotHandle(ctx, tokenValue)
+	if err != nil {
+		return err
+	}
+	if !t.isValidLoginId(loginId) {
+		return nil
+	}
+	ss, err := t.getSessionByLoginId(ctx, loginId, false)
+	if err != nil {
+		return err
+	}
+	if err := t.offline(ctx, ss, tokenValue, KickOut); err != nil {
+		return err
+	}
+	t.config.listener.DoKickOut(t.config.LoginType, loginId, tokenValue)
+	if ss == nil {
+		return nil
+	}
+	return ss.logoutByTokenSignCountIsZero(ctx)
+}
+
+// offline 让 token 下线：清除签名和最后活跃时间，并把 token 标记为指定的异常标记
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param ss *session 账号的 Account-Session，可以为nil
+//	@param tokenValue string token值
+//	@param marker string 异常标记，例如 BeReplaced 和 KickOut
+//	@return error
+func (t *token) offline(ctx context.Context, ss *session, tokenValue string, marker string) error {
+	// 1、从 Account-Session 上清除 token 签名
+	if ss != nil {
+		if err := ss.removeTokenSign(ctx, tokenValue); err != nil {
+			return err
+		}
+	}
+	// 2、清除这个 token 的最后活跃时间记录
+	if t.isOpenCheckActiveTimeout() {
+		if err := t.clearLastActive(tokenValue); err != nil {
+			return err
+		}
+	}
+	// 3、将此 token 标记为异常标记
+	if err := t.updateTokenToIdMapping(ctx, tokenValue, marker); err != nil {
+		return err
+	}
+	if err := t.denyJWT(ctx, tokenValue, marker); err != nil {
+		return err
+	}
+	// 4、吊销此 token 对应的 refresh token 令牌族
+	return t.revokeRefreshByAccessToken(ctx, tokenValue)
+}
+
+// search 按前缀搜索 key，并去掉前缀返回
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param prefix string key前缀
+//	@param keyword string 关键字
+//	@param start int 开始下标
+//	@param size int 获取数量
+//	@param sortAsc bool 是否升序
+//	@return []string
+//	@return error
+func (t *token) search(ctx context.Context, prefix, keyword string, start, size int, sortAsc bool) ([]string, error) {
+	if t.isStateless() {
+		return nil, ErrStateless
+	}
+	repo, ok := t.config.repository.(SearchRepository)
+	if !ok {
+		return nil, ErrSearchNotSupported
+	}
+	keys, err := repo.Search(ctx, prefix, keyword, start, size, sortAsc)
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, prefix)
+	}
+	return keys, nil
+}
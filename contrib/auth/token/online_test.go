@@ -0,0 +1,100 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestKickOut(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	defer repo.Close()
+
+	conf := DefaultConfig().WithOptions(WithRepository(repo))
+	conf.IsShare = false
+	tk := conf.Build()
+	pc, err := tk.Login(ctx, 1001, LoginWithDevice("pc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app, err := tk.Login(ctx, 1001, LoginWithDevice("app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tk.Login(ctx, 1002); err != nil {
+		t.Fatal(err)
+	}
+	signs, err := tk.GetSignList(ctx, 1001, "")
+	if err != nil || len(signs) != 2 {
+		t.Fatalf("signs = %d, err = %v", len(signs), err)
+	}
+	tokens, err := tk.SearchTokenValue(ctx, "", 0, -1, true)
+	if err != nil || len(tokens) != 3 {
+		t.Fatalf("tokens = %v, err = %v", tokens, err)
+	}
+	sessions, err := tk.SearchSessionId(ctx, "", 1, 1, true)
+	if err != nil || len(sessions) != 1 || sessions[0] != "1002" {
+		t.Fatalf("sessions = %v, err = %v", sessions, err)
+	}
+	if ttl, _ := tk.GetTokenTimeout(ctx, pc); ttl <= 0 {
+		t.Fatalf("token timeout = %d, want > 0", ttl)
+	}
+
+	if err := tk.KickOut(ctx, 1001, "pc"); err != nil {
+		t.Fatal(err)
+	}
+	var nle *NotLoginError
+	if _, err := tk.GetLoginId(ctx, pc); !errors.As(err, &nle) || nle.GetType() != KickOut {
+		t.Fatalf("err = %v, want kick out", err)
+	}
+	if _, err := tk.GetLoginId(ctx, app); err != nil {
+		t.Fatalf("other device should stay online, got %v", err)
+	}
+	// 被踢下线的 token 不在搜索结果中
+	if tokens, err = tk.SearchTokenValue(ctx, "", 0, -1, true); err != nil || len(tokens) != 2 {
+		t.Fatalf("tokens after kick out = %v, err = %v", tokens, err)
+	}
+	for _, tokenValue := range tokens {
+		if tokenValue == pc {
+			t.Fatal("kicked token should not be searched")
+		}
+	}
+	if err := tk.KickOutByTokenValue(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	if signs, _ := tk.GetSignList(ctx, 1001, ""); len(signs) != 0 {
+		t.Fatalf("signs = %d, want 0", len(signs))
+	}
+}
+
+// baseListener 只实现了 Listener 接口的监听器
+type baseListener struct {
+	logins chan string
+}
+
+func (l *baseListener) DoLogin(_ string, loginId any, _ string, _ LoginOptions) {
+	l.logins <- loginId.(string)
+}
+func (l *baseListener) DoLogout(string, any, string)              {}
+func (l *baseListener) DoReplaced(string, any, string)            {}
+func (l *baseListener) DoDisable(string, any, string, int, int64) {}
+
+func TestBaseListener(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	defer repo.Close()
+
+	listener := &baseListener{logins: make(chan string, 1)}
+	tk := New(WithRepository(repo), WithAppendListener(listener))
+	if _, err := tk.Login(ctx, "1001"); err != nil {
+		t.Fatal(err)
+	}
+	if loginId := <-listener.logins; loginId != "1001" {
+		t.Fatalf("loginId = %s", loginId)
+	}
+	// 没有实现 KickOutListener 的监听器不接收踢人下线事件
+	if err := tk.KickOut(ctx, "1001", ""); err != nil {
+		t.Fatal(err)
+	}
+}
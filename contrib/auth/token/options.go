@@ -37,6 +37,7 @@ const (
 	StyleSimpleUUID Style = "simple-uuid" // StyleSimpleUUID uuid不带下划线
 	StyleRandom32   Style = "random-32"   // StyleRandom32 随机32位字符串
 	StyleRandom64   Style = "random-64"   // StyleRandom64 随机64位字符串
+	StyleJWT        Style = "jwt"         // StyleJWT jwt，登录账号、设备、登录类型和过期时间写入 claims
 )
 
 // Option 创建参数
@@ -142,6 +143,67 @@ func WithAutoRenew(autoRenew bool) Option {
 	}
 }
 
+// WithJWTMode 设置 jwt 模式，只有在 Style 为 StyleJWT 时有效
+//
+//	@param mode JWTMode jwt模式
+//	@return Option
+func WithJWTMode(mode JWTMode) Option {
+	return func(o *Config) {
+		o.JWTMode = mode
+	}
+}
+
+// WithJWTSecret 设置 HS256 签名密钥
+//
+//	@param secret string 密钥
+//	@return Option
+func WithJWTSecret(secret string) Option {
+	return func(o *Config) {
+		o.JWTSecret = secret
+	}
+}
+
+// WithJWTKeys 设置 jwt 密钥，第一个可以签名的密钥用于签发 token，全部密钥都可用于校验，
+// 轮换密钥时把新密钥放在最前面，旧密钥保留到已签发的 token 全部过期
+//
+//	@param keys ...*JWTKey 密钥列表
+//	@return Option
+func WithJWTKeys(keys ...*JWTKey) Option {
+	return func(o *Config) {
+		o.jwtKeys = keys
+	}
+}
+
+// WithRefreshTimeout 设置 refresh token 有效期（单位：秒），0 表示不签发 refresh token
+//
+//	@param timeout int64
+//	@return Option
+func WithRefreshTimeout(timeout int64) Option {
+	return func(o *Config) {
+		o.RefreshTimeout = timeout
+	}
+}
+
+// WithPermissionProvider 设置角色和权限数据提供者
+//
+//	@param provider PermissionProvider
+//	@return Option
+func WithPermissionProvider(provider PermissionProvider) Option {
+	return func(o *Config) {
+		o.permissionProvider = provider
+	}
+}
+
+// WithPermissionCacheTimeout 设置角色和权限的缓存时间（单位：秒），0 表示不缓存
+//
+//	@param timeout int64
+//	@return Option
+func WithPermissionCacheTimeout(timeout int64) Option {
+	return func(o *Config) {
+		o.PermissionCacheTimeout = timeout
+	}
+}
+
 // WithCreateTokenFunction 设置token创建方法
 //
 //	@param createTokenFunction CreateTokenFunction 创建token的方法
@@ -0,0 +1,415 @@
+// Package token
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package token
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/duke-git/lancet/v2/convertor"
+
+	foxErrors "github.com/go-fox/fox/errors"
+	"github.com/go-fox/fox/middleware"
+	"github.com/go-fox/fox/transport"
+)
+
+// Mode 校验多个角色或权限时的模式
+type Mode string
+
+const (
+	ModeAnd Mode = "and" // ModeAnd 必须全部满足
+	ModeOr  Mode = "or"  // ModeOr 满足其中一个即可
+)
+
+const (
+	ReasonPermissionDenied = "PERMISSION_DENIED" // ReasonPermissionDenied 没有访问接口需要的权限
+	ReasonRoleDenied       = "ROLE_DENIED"       // ReasonRoleDenied 没有访问接口需要的角色
+)
+
+// PermissionProvider 角色和权限数据提供者，由业务方实现
+type PermissionProvider interface {
+	// GetRoles 获取账号拥有的角色
+	//
+	//  @param ctx context.Context
+	//  @param loginId any 登录账号id
+	//  @param loginType string 登录类型
+	//  @return []string 角色列表
+	//  @return error 是否有错
+	GetRoles(ctx context.Context, loginId any, loginType string) ([]string, error)
+	// GetPermissions 获取账号拥有的权限码，支持通配符，例如 user:*:read
+	//
+	//  @param ctx context.Context
+	//  @param loginId any 登录账号id
+	//  @param loginType string 登录类型
+	//  @return []string 权限码列表
+	//  @return error 是否有错
+	GetPermissions(ctx context.Context, loginId any, loginType string) ([]string, error)
+}
+
+// permissionCache 缓存在 Repository 中的角色和权限
+type permissionCache struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+// MatchPermission 判断拥有的权限码是否匹配需要的权限码，以 : 分段，* 匹配任意一段，
+// 末尾的 * 匹配剩余所有分段，例如 user:*:read 匹配 user:profile:read，user:* 匹配 user:profile:write
+//
+//	@param pattern string 拥有的权限码
+//	@param permission string 需要的权限码
+//	@return bool 是否匹配
+func MatchPermission(pattern, permission string) bool {
+	if pattern == permission || pattern == "*" {
+		return true
+	}
+	patterns := strings.Split(pattern, ":")
+	parts := strings.Split(permission, ":")
+	for i, p := range patterns {
+		if i >= len(parts) {
+			return false
+		}
+		if p == "*" {
+			if i == len(patterns)-1 {
+				return true
+			}
+			continue
+		}
+		if p != parts[i] {
+			return false
+		}
+	}
+	return len(patterns) == len(parts)
+}
+
+// GetRoles 获取账号拥有的角色
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param loginId any 登录账号id
+//	@return []string 角色列表
+//	@return error 是否有错
+func (t *token) GetRoles(ctx context.Context, loginId any) ([]string, error) {
+	c, err := t.getPermissionCache(ctx, loginId)
+	if err != nil {
+		return nil, err
+	}
+	return c.Roles, nil
+}
+
+// GetPermissions 获取账号拥有的权限码
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param loginId any 登录账号id
+//	@return []string 权限码列表
+//	@return error 是否有错
+func (t *token) GetPermissions(ctx context.Context, loginId any) ([]string, error) {
+	c, err := t.getPermissionCache(ctx, loginId)
+	if err != nil {
+		return nil, err
+	}
+	return c.Permissions, nil
+}
+
+// HasRole 判断账号是否拥有指定角色
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param loginId any 登录账号id
+//	@param role string 角色
+//	@return bool 是否拥有
+//	@return error 是否有错
+func (t *token) HasRole(ctx context.Context, loginId any, role string) (bool, error) {
+	err := t.CheckRole(ctx, loginId, ModeAnd, role)
+	return err == nil, ignoreDenied(err)
+}
+
+// HasPermission 判断账号是否拥有指定权限
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param loginId any 登录账号id
+//	@param permission string 权限码
+//	@return bool 是否拥有
+//	@return error 是否有错
+func (t *token) HasPermission(ctx context.Context, loginId any, permission string) (bool, error) {
+	err := t.CheckPermission(ctx, loginId, ModeAnd, permission)
+	return err == nil, ignoreDenied(err)
+}
+
+// CheckRole 校验账号是否拥有指定角色，不满足时返回 NotRoleError
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param loginId any 登录账号id
+//	@param mode Mode 校验模式
+//	@param roles ...string 角色列表
+//	@return error
+func (t *token) CheckRole(ctx context.Context, loginId any, mode Mode, roles ...string) error {
+	owned, err := t.GetRoles(ctx, loginId)
+	if err != nil {
+		return err
+	}
+	missing := matchMode(owned, roles, mode, func(owned, need string) bool { return owned == need })
+	if len(missing) > 0 {
+		return NewNotRoleError(t.config.LoginType, missing)
+	}
+	return nil
+}
+
+// CheckPermission 校验账号是否拥有指定权限，不满足时返回 NotPermissionError
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param loginId any 登录账号id
+//	@param mode Mode 校验模式
+//	@param permissions ...string 权限码列表
+//	@return error
+func (t *token) CheckPermission(ctx context.Context, loginId any, mode Mode, permissions ...string) error {
+	owned, err := t.GetPermissions(ctx, loginId)
+	if err != nil {
+		return err
+	}
+	missing := matchMode(owned, permissions, mode, MatchPermission)
+	if len(missing) > 0 {
+		return NewNotPermissionError(t.config.LoginType, missing)
+	}
+	return nil
+}
+
+// ClearPermissionCache 清除账号缓存的角色和权限，账号授权变更后调用
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param loginId any 登录账号id
+//	@return error
+func (t *token) ClearPermissionCache(ctx context.Context, loginId any) error {
+	if !t.isPermissionCacheEnabled() {
+		return nil
+	}
+	return t.config.repository.Delete(ctx, t.splicingKeyPermission(loginId))
+}
+
+// getPermissionCache 获取账号的角色和权限，开启缓存时优先从 Repository 中读取
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param loginId any 登录账号id
+//	@return *permissionCache
+//	@return error
+func (t *token) getPermissionCache(ctx context.Context, loginId any) (*permissionCache, error) {
+	if t.config.permissionProvider == nil {
+		return nil, errors.New("PermissionProvider 未配置")
+	}
+	if len(convertor.ToString(loginId)) == 0 {
+		return nil, errors.New("loginId 不能为空")
+	}
+	key := t.splicingKeyPermission(loginId)
+	c := &permissionCache{}
+	if t.isPermissionCacheEnabled() {
+		if err := t.config.repository.Get(ctx, key, c); err != nil {
+			return nil, err
+		}
+		if c.Roles != nil || c.Permissions != nil {
+			return c, nil
+		}
+	}
+	var err error
+	if c.Roles, err = t.config.permissionProvider.GetRoles(ctx, loginId, t.config.LoginType); err != nil {
+		return nil, err
+	}
+	if c.Permissions, err = t.config.permissionProvider.GetPermissions(ctx, loginId, t.config.LoginType); err != nil {
+		return nil, err
+	}
+	// 缓存空列表而不是 nil，以便区分没有缓存和没有权限
+	if c.Roles == nil {
+		c.Roles = []string{}
+	}
+	if c.Permissions == nil {
+		c.Permissions = []string{}
+	}
+	if t.isPermissionCacheEnabled() {
+		if err := t.config.repository.Set(ctx, key, c, time.Duration(t.config.PermissionCacheTimeout)*time.Second); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// isPermissionCacheEnabled 是否开启了角色和权限缓存
+//
+//	@receiver t
+//	@return bool
+func (t *token) isPermissionCacheEnabled() bool {
+	return t.config.PermissionCacheTimeout != 0 && t.config.repository != nil && !t.isStateless()
+}
+
+// splicingKeyPermission 拼接：缓存角色和权限使用的key
+//
+//	@receiver t
+//	@param loginId any 登录账号id
+//	@return string
+func (t *token) splicingKeyPermission(loginId any) string {
+	return t.config.TokenName + ":" + t.config.LoginType + ":permission:" + convertor.ToString(loginId)
+}
+
+// matchMode 校验拥有的值是否满足需要的值，返回第一个不满足的值，全部满足时返回空字符串
+//
+//	@param owned []string 拥有的值
+//	@param need []string 需要的值
+//	@param mode Mode 校验模式
+//	@param match func(owned, need string) bool 匹配方法
+//	@return string
+func matchMode(owned, need []string, mode Mode, match func(owned, need string) bool) string {
+	has := func(n string) bool {
+		for _, o := range owned {
+			if match(o, n) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, n := range need {
+		ok := has(n)
+		if mode == ModeOr && ok {
+			return ""
+		}
+		if mode != ModeOr && !ok {
+			return n
+		}
+	}
+	if mode == ModeOr && len(need) > 0 {
+		return need[0]
+	}
+	return ""
+}
+
+// ignoreDenied 忽略没有角色和没有权限错误
+//
+//	@param err error
+//	@return error
+func ignoreDenied(err error) error {
+	var nre *NotRoleError
+	var npe *NotPermissionError
+	if errors.As(err, &nre) || errors.As(err, &npe) {
+		return nil
+	}
+	return err
+}
+
+// PermissionRule 接口需要的角色和权限，同时声明时两者都需要满足
+type PermissionRule struct {
+	Roles       []string // 需要的角色
+	Permissions []string // 需要的权限码
+	Mode        Mode     // 校验模式，默认 ModeAnd
+}
+
+// Permissions 接口与需要的角色和权限的映射，key 为 operation，末尾为 * 时按前缀匹配，
+// 例如 /api.user.v1.User/* 匹配 User 服务下的所有接口，精确匹配优先，其次为最长的前缀
+type Permissions map[string]PermissionRule
+
+// match 查找 operation 对应的规则
+//
+//	@receiver p
+//	@param operation string
+//	@return PermissionRule
+//	@return bool 是否声明了规则
+func (p Permissions) match(operation string) (PermissionRule, bool) {
+	if rule, ok := p[operation]; ok {
+		return rule, true
+	}
+	var (
+		rule   PermissionRule
+		prefix = -1
+	)
+	for key, r := range p {
+		if !strings.HasSuffix(key, "*") {
+			continue
+		}
+		k := strings.TrimSuffix(key, "*")
+		if len(k) > prefix && strings.HasPrefix(operation, k) {
+			rule, prefix = r, len(k)
+		}
+	}
+	return rule, prefix >= 0
+}
+
+// Authorize 鉴权中间件，校验当前登录账号是否拥有接口声明的角色和权限，需要放在 Server 中间件之后，
+// 没有声明规则的接口直接放行，例如：
+//
+//	http.Middleware(token.Server(t), token.Authorize(t, token.Permissions{
+//		"/api.user.v1.User/Delete": {Permissions: []string{"user:delete"}},
+//		"/api.admin.v1.Admin/*":    {Roles: []string{"admin"}},
+//	}))
+//
+//	@param t Authorizer token实例
+//	@param perms Permissions 接口需要的角色和权限
+//	@return middleware.Middleware
+func Authorize(t Authorizer, perms Permissions) middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			rule, ok := perms.match(tr.Operation())
+			if !ok {
+				return handler(ctx, req)
+			}
+			info, ok := FromContext(ctx)
+			if !ok {
+				return nil, foxErrors.Unauthorized(ReasonTokenMissing, "未登录")
+			}
+			if len(rule.Roles) > 0 {
+				if err := t.CheckRole(ctx, info.LoginId, rule.Mode, rule.Roles...); err != nil {
+					return nil, forbidden(err)
+				}
+			}
+			if len(rule.Permissions) > 0 {
+				if err := t.CheckPermission(ctx, info.LoginId, rule.Mode, rule.Permissions...); err != nil {
+					return nil, forbidden(err)
+				}
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+// forbidden 把 NotRoleError 和 NotPermissionError 转换为 errors.Forbidden，其他错误原样返回
+//
+//	@param err error
+//	@return error
+func forbidden(err error) error {
+	var nre *NotRoleError
+	if errors.As(err, &nre) {
+		return foxErrors.Forbidden(ReasonRoleDenied, nre.Error()).WithMetadata(map[string]string{"role": nre.GetRole()}).WithCause(err)
+	}
+	var npe *NotPermissionError
+	if errors.As(err, &npe) {
+		return foxErrors.Forbidden(ReasonPermissionDenied, npe.Error()).WithMetadata(map[string]string{"permission": npe.GetPermission()}).WithCause(err)
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+package token
+
+import (
+	"context"
+	"testing"
+
+	foxErrors "github.com/go-fox/fox/errors"
+	"github.com/go-fox/fox/transport"
+)
+
+type testTransport struct {
+	transport.Transporter
+	operation string
+}
+
+func (tr *testTransport) Operation() string {
+	return tr.operation
+}
+
+type testProvider struct {
+	roles       []string
+	permissions []string
+}
+
+func (p *testProvider) GetRoles(ctx context.Context, loginId any, loginType string) ([]string, error) {
+	return p.roles, nil
+}
+
+func (p *testProvider) GetPermissions(ctx context.Context, loginId any, loginType string) ([]string, error) {
+	return p.permissions, nil
+}
+
+func TestMatchPermission(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		permission string
+		want       bool
+	}{
+		{"*", "user:profile:read", true},
+		{"user:*:read", "user:profile:read", true},
+		{"user:*:read", "user:profile:write", false},
+		{"user:*", "user:profile:write", true},
+		{"user:profile", "user:profile:read", false},
+		{"user:profile:read", "user:profile", false},
+		{"order:*:read", "user:profile:read", false},
+	}
+	for _, tt := range tests {
+		if got := MatchPermission(tt.pattern, tt.permission); got != tt.want {
+			t.Errorf("MatchPermission(%q, %q) = %v, want %v", tt.pattern, tt.permission, got, tt.want)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	provider := &testProvider{roles: []string{"editor"}, permissions: []string{"user:*:read"}}
+	tk := New(WithStyle(StyleJWT), WithJWTMode(JWTModeStateless), WithJWTSecret("secret"), WithPermissionProvider(provider))
+	ctx := context.Background()
+	if ok, err := tk.HasPermission(ctx, 1001, "user:profile:read"); err != nil || !ok {
+		t.Fatalf("HasPermission = %v, err = %v", ok, err)
+	}
+	if err := tk.CheckRole(ctx, 1001, ModeOr, "admin", "editor"); err != nil {
+		t.Fatalf("CheckRole(or) err = %v", err)
+	}
+	if err := tk.CheckRole(ctx, 1001, ModeAnd, "admin", "editor"); err == nil {
+		t.Fatal("CheckRole(and) should fail without admin")
+	}
+
+	m := Authorize(tk, Permissions{
+		"/api.user.v1.User/Get":    {Permissions: []string{"user:profile:read"}},
+		"/api.user.v1.User/Delete": {Permissions: []string{"user:profile:delete"}},
+		"/api.admin.v1.Admin/*":    {Roles: []string{"admin"}},
+	})
+	h := m(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	call := func(operation string, login bool) error {
+		ctx := transport.NewServerContext(context.Background(), &testTransport{operation: operation})
+		if login {
+			ctx = NewContext(ctx, &LoginInfo{LoginId: "1001"})
+		}
+		_, err := h(ctx, nil)
+		return err
+	}
+	if err := call("/api.user.v1.User/Get", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := call("/api.user.v1.User/List", false); err != nil {
+		t.Fatalf("operation without rule should pass, got %v", err)
+	}
+	if err := call("/api.user.v1.User/Get", false); !foxErrors.IsUnauthorized(err) {
+		t.Fatalf("err = %v, want unauthorized", err)
+	}
+	if err := call("/api.user.v1.User/Delete", true); foxErrors.Reason(err) != ReasonPermissionDenied {
+		t.Fatalf("err = %v, want permission denied", err)
+	}
+	if err := call("/api.admin.v1.Admin/Stats", true); foxErrors.Reason(err) != ReasonRoleDenied {
+		t.Fatalf("err = %v, want role denied", err)
+	}
+}
@@ -0,0 +1,452 @@
+// Package token
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package token
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/duke-git/lancet/v2/convertor"
+	"github.com/duke-git/lancet/v2/random"
+)
+
+var (
+	// ErrRefreshDisabled 未开启 refresh token
+	ErrRefreshDisabled = errors.New("token: refresh token is disabled")
+	// ErrRefreshTokenInvalid refresh token 无效或已过期
+	ErrRefreshTokenInvalid = errors.New("token: refresh token is invalid")
+	// ErrRefreshTokenReused refresh token 被重复使用，整个令牌族已被吊销
+	ErrRefreshTokenReused = errors.New("token: refresh token is reused")
+	// ErrAccountDisabled 账号已被封禁
+	ErrAccountDisabled = errors.New("token: account is disabled")
+)
+
+// TokenPair 登录凭证对
+type TokenPair struct {
+	AccessToken    string `json:"access_token"`    // 访问 token
+	RefreshToken   string `json:"refresh_token"`   // 刷新 token
+	AccessTimeout  int64  `json:"access_timeout"`  // 访问 token 有效期（单位：秒）
+	RefreshTimeout int64  `json:"refresh_timeout"` // 刷新 token 有效期（单位：秒）
+}
+
+// refreshRecord 保存在 Repository 中的 refresh token 信息
+type refreshRecord struct {
+	LoginId       string         `json:"login_id"`
+	Family        string         `json:"family"`         // 令牌族标识，同一次登录轮换出的 refresh token 属于同一族
+	Used          bool           `json:"used"`           // 是否已被使用
+	Device        string         `json:"device"`         // 登录设备
+	Timeout       int64          `json:"timeout"`        // 访问 token 有效期
+	ActiveTimeout int64          `json:"active_timeout"` // 访问 token 最低活跃频率
+	Extra         map[string]any `json:"extra"`          // 额外数据
+}
+
+// refreshFamily 令牌族当前有效的凭证
+type refreshFamily struct {
+	RefreshToken string `json:"refresh_token"`
+	AccessToken  string `json:"access_token"`
+	ExpireAt     int64  `json:"expire_at"` // 令牌族的过期时间（unix 秒），轮换不会延长，0 表示永不过期
+}
+
+// LoginPair 登录并签发访问 token 和 refresh token，需要配置 RefreshTimeout
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param loginId any 推荐使用（int64，int，string）类型
+//	@param opts ...LoginOption 登录参数
+//	@return *TokenPair
+//	@return error
+func (t *token) LoginPair(ctx context.Context, loginId any, opts ...LoginOption) (*TokenPair, error) {
+	if err := t.checkRefresh(); err != nil {
+		return nil, err
+	}
+	o := LoginOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.Apply(t.config)
+	var expireAt int64
+	if t.config.RefreshTimeout > 0 {
+		expireAt = time.Now().Unix() + t.config.RefreshTimeout
+	}
+	return t.issuePair(ctx, loginId, random.RandString(32), expireAt, o)
+}
+
+// Refresh 使用 refresh token 轮换出新的访问 token 和 refresh token，旧的凭证立即失效，
+// 已使用过的 refresh token 再次使用时视为泄露，吊销整个令牌族并返回 ErrRefreshTokenReused，
+// 当前的访问 token 已被踢下线或顶下线时吊销令牌族并返回 ErrRefreshTokenInvalid，账号被封禁时返回 ErrAccountDisabled
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param refreshToken string refresh token
+//	@return *TokenPair
+//	@return error
+func (t *token) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	if err := t.checkRefresh(); err != nil {
+		return nil, err
+	}
+	if len(refreshToken) == 0 {
+		return nil, ErrRefreshTokenInvalid
+	}
+	// Repository 不支持原子操作时在进程内串行化轮换
+	var err error
+	atomicRepo, atomic := t.config.repository.(AtomicRepository)
+	if !atomic {
+		t.refreshMu.Lock()
+		defer t.refreshMu.Unlock()
+	}
+	// 1、获取 refresh token 信息
+	record := &refreshRecord{}
+	if err = t.config.repository.Get(ctx, t.splicingKeyRefresh(refreshToken), record); err != nil {
+		return nil, err
+	}
+	if len(record.Family) == 0 {
+		return nil, ErrRefreshTokenInvalid
+	}
+	family := &refreshFamily{}
+	if err = t.config.repository.Get(ctx, t.splicingKeyRefreshFamily(record.Family), family); err != nil {
+		return nil, err
+	}
+	// 2、已经使用过或者不是令牌族当前的 refresh token，吊销整个令牌族
+	if record.Used || family.RefreshToken != refreshToken {
+		return nil, t.refreshReused(ctx, record, family, refreshToken)
+	}
+	// 3、当前的访问 token 已被踢下线或顶下线时吊销令牌族，账号被封禁时拒绝轮换
+	if err = t.checkRefreshSession(ctx, record, family); err != nil {
+		return nil, err
+	}
+	// 4、原子占用 refresh token，多个节点同时轮换时只有一个能成功
+	claimKey := t.splicingKeyRefreshClaim(refreshToken)
+	if atomic {
+		ok, err := atomicRepo.SetNX(ctx, claimKey, record.Family, time.Duration(t.config.RefreshTimeout)*time.Second)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, t.refreshReused(ctx, record, family, refreshToken)
+		}
+	}
+	// 5、在同一令牌族下签发新的凭证，失败时释放占用，旧的凭证仍然有效
+	o := LoginOptions{
+		device:        record.Device,
+		timeout:       record.Timeout,
+		activeTimeout: record.ActiveTimeout,
+		extraData:     record.Extra,
+	}
+	// 旧的访问 token 稍后注销，预先生成新的 token 避免复用
+	o.token, err = t.newTokenValue(ctx, record.LoginId, o)
+	// 签发新的访问 token 时可能顶掉或注销旧的访问 token，先解除旧的访问 token 和令牌族的映射，避免吊销正在轮换的令牌族
+	accessKey := t.splicingKeyRefreshAccess(family.AccessToken)
+	if err == nil {
+		err = t.config.repository.Delete(ctx, accessKey)
+	}
+	var pair *TokenPair
+	if err == nil {
+		pair, err = t.issuePair(ctx, record.LoginId, record.Family, family.ExpireAt, o)
+		if err != nil {
+			_ = t.config.repository.Set(ctx, accessKey, record.Family, refreshTTL(family.ExpireAt))
+		}
+	}
+	if err != nil {
+		if atomic {
+			_ = t.config.repository.Delete(ctx, claimKey)
+		}
+		return nil, err
+	}
+	// 6、标记旧的 refresh token 已使用并注销旧的访问 token，令牌族已指向新的凭证，
+	// 失败时旧的 refresh token 也无法再次使用
+	record.Used = true
+	if err = t.config.repository.Update(ctx, t.splicingKeyRefresh(refreshToken), record); err != nil {
+		t.config.logger.Warn("标记 refresh token 已使用失败", "loginId", record.LoginId, "error", err)
+	}
+	if err = t.LogoutByTokenValue(ctx, family.AccessToken); err != nil {
+		t.config.logger.Warn("注销旧的访问 token 失败", "loginId", record.LoginId, "error", err)
+	}
+	t.config.listener.DoRefresh(t.config.LoginType, record.LoginId, pair.AccessToken)
+	return pair, nil
+}
+
+// RevokeRefreshToken 吊销 refresh token 所在的整个令牌族，并注销当前的访问 token
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param refreshToken string refresh token
+//	@return error
+func (t *token) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	if err := t.checkRefresh(); err != nil {
+		return err
+	}
+	if len(refreshToken) == 0 {
+		return nil
+	}
+	t.refreshMu.Lock()
+	defer t.refreshMu.Unlock()
+	record := &refreshRecord{}
+	if err := t.config.repository.Get(ctx, t.splicingKeyRefresh(refreshToken), record); err != nil {
+		return err
+	}
+	if len(record.Family) == 0 {
+		return nil
+	}
+	family := &refreshFamily{}
+	if err := t.config.repository.Get(ctx, t.splicingKeyRefreshFamily(record.Family), family); err != nil {
+		return err
+	}
+	return t.revokeFamily(ctx, record.Family, family)
+}
+
+// issuePair 登录并在指定令牌族下签发 refresh token
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param loginId any 登录账号id
+//	@param familyId string 令牌族标识
+//	@param expireAt int64 令牌族的过期时间（unix 秒），0 表示永不过期
+//	@param o LoginOptions 登录参数
+//	@return *TokenPair
+//	@return error
+func (t *token) issuePair(ctx context.Context, loginId any, familyId string, expireAt int64, o LoginOptions) (*TokenPair, error) {
+	// 令牌族的有效期从首次登录开始计算，轮换签发的 refresh token 不超过令牌族的过期时间
+	timeout := refreshTTL(expireAt)
+	if expireAt > 0 && timeout <= 0 {
+		return nil, ErrRefreshTokenInvalid
+	}
+	accessToken, err := t.Login(ctx, loginId, func(lo *LoginOptions) {
+		*lo = o
+	})
+	if err != nil {
+		return nil, err
+	}
+	refreshToken := random.RandString(64)
+	// 写入失败时注销刚签发的访问 token
+	rollback := func(err error) (*TokenPair, error) {
+		_ = t.config.repository.Delete(ctx, t.splicingKeyRefresh(refreshToken))
+		_ = t.config.repository.Delete(ctx, t.splicingKeyRefreshAccess(accessToken))
+		_ = t.LogoutByTokenValue(ctx, accessToken)
+		return nil, err
+	}
+	record := &refreshRecord{
+		LoginId:       convertor.ToString(loginId),
+		Family:        familyId,
+		Device:        o.GetDevice(),
+		Timeout:       o.GetTimeout(),
+		ActiveTimeout: o.GetActiveTimeout(),
+		Extra:         o.GetExtraData(),
+	}
+	if err := t.config.repository.Set(ctx, t.splicingKeyRefresh(refreshToken), record, timeout); err != nil {
+		return rollback(err)
+	}
+	// 访问 token 注销或被踢下线时通过此映射吊销令牌族
+	if err := t.config.repository.Set(ctx, t.splicingKeyRefreshAccess(accessToken), familyId, timeout); err != nil {
+		return rollback(err)
+	}
+	family := &refreshFamily{RefreshToken: refreshToken, AccessToken: accessToken, ExpireAt: expireAt}
+	if err := t.config.repository.Set(ctx, t.splicingKeyRefreshFamily(familyId), family, timeout); err != nil {
+		return rollback(err)
+	}
+	refreshTimeout := NeverExpire
+	if expireAt > 0 {
+		refreshTimeout = int64(math.Ceil(timeout.Seconds()))
+	}
+	return &TokenPair{
+		AccessToken:    accessToken,
+		RefreshToken:   refreshToken,
+		AccessTimeout:  o.GetTimeout(),
+		RefreshTimeout: refreshTimeout,
+	}, nil
+}
+
+// refreshReused refresh token 被重复使用，吊销整个令牌族
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param record *refreshRecord 被重复使用的 refresh token 信息
+//	@param family *refreshFamily 令牌族当前有效的凭证
+//	@param refreshToken string 被重复使用的 refresh token
+//	@return error
+func (t *token) refreshReused(ctx context.Context, record *refreshRecord, family *refreshFamily, refreshToken string) error {
+	if err := t.revokeFamily(ctx, record.Family, family); err != nil {
+		return err
+	}
+	t.config.listener.DoRefreshReuse(t.config.LoginType, record.LoginId, refreshToken)
+	return ErrRefreshTokenReused
+}
+
+// checkRefreshSession 检查令牌族当前的访问 token 和账号状态，访问 token 自然过期时仍然可以轮换
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param record *refreshRecord refresh token 信息
+//	@param family *refreshFamily 令牌族当前有效的凭证
+//	@return error
+func (t *token) checkRefreshSession(ctx context.Context, record *refreshRecord, family *refreshFamily) error {
+	loginId, err := t.getLoginIdNotHandle(ctx, family.AccessToken)
+	if err != nil {
+		return err
+	}
+	// 被踢下线、顶下线或者已经映射到其他账号时吊销令牌族，保留访问 token 的异常标记
+	if len(loginId) > 0 && loginId != record.LoginId && loginId != TimeoutToken {
+		if err := t.deleteFamily(ctx, record.Family, family); err != nil {
+			return err
+		}
+		return ErrRefreshTokenInvalid
+	}
+	var level string
+	if err := t.config.repository.Get(ctx, t.splicingKeyDisable(record.LoginId, DefaultDisableService), &level); err != nil {
+		return err
+	}
+	if len(level) > 0 {
+		return ErrAccountDisabled
+	}
+	return nil
+}
+
+// revokeRefreshByAccessToken 访问 token 注销或被踢下线时吊销对应的令牌族，
+// 令牌族已经轮换到新的访问 token 时只清除映射
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param accessToken string 访问 token
+//	@return error
+func (t *token) revokeRefreshByAccessToken(ctx context.Context, accessToken string) error {
+	if t.config.RefreshTimeout == 0 || len(accessToken) == 0 {
+		return nil
+	}
+	key := t.splicingKeyRefreshAccess(accessToken)
+	var familyId string
+	if err := t.config.repository.Get(ctx, key, &familyId); err != nil {
+		return err
+	}
+	if len(familyId) == 0 {
+		return nil
+	}
+	if err := t.config.repository.Delete(ctx, key); err != nil {
+		return err
+	}
+	family := &refreshFamily{}
+	if err := t.config.repository.Get(ctx, t.splicingKeyRefreshFamily(familyId), family); err != nil {
+		return err
+	}
+	if family.AccessToken != accessToken {
+		return nil
+	}
+	return t.deleteFamily(ctx, familyId, family)
+}
+
+// revokeFamily 吊销令牌族：删除当前的 refresh token 和令牌族，并注销当前的访问 token
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param familyId string 令牌族标识
+//	@param family *refreshFamily 令牌族当前有效的凭证
+//	@return error
+func (t *token) revokeFamily(ctx context.Context, familyId string, family *refreshFamily) error {
+	if err := t.deleteFamily(ctx, familyId, family); err != nil {
+		return err
+	}
+	return t.LogoutByTokenValue(ctx, family.AccessToken)
+}
+
+// deleteFamily 删除令牌族当前的 refresh token、访问 token 映射和令牌族，不处理访问 token
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param familyId string 令牌族标识
+//	@param family *refreshFamily 令牌族当前有效的凭证
+//	@return error
+func (t *token) deleteFamily(ctx context.Context, familyId string, family *refreshFamily) error {
+	if len(family.RefreshToken) > 0 {
+		if err := t.config.repository.Delete(ctx, t.splicingKeyRefresh(family.RefreshToken)); err != nil {
+			return err
+		}
+	}
+	if len(family.AccessToken) > 0 {
+		if err := t.config.repository.Delete(ctx, t.splicingKeyRefreshAccess(family.AccessToken)); err != nil {
+			return err
+		}
+	}
+	return t.config.repository.Delete(ctx, t.splicingKeyRefreshFamily(familyId))
+}
+
+// refreshTTL 令牌族的剩余有效期
+//
+//	@param expireAt int64 令牌族的过期时间（unix 秒），0 表示永不过期
+//	@return time.Duration
+func refreshTTL(expireAt int64) time.Duration {
+	if expireAt == 0 {
+		return time.Duration(NeverExpire) * time.Second
+	}
+	return time.Until(time.Unix(expireAt, 0))
+}
+
+// checkRefresh 检查是否可以使用 refresh token
+//
+//	@receiver t
+//	@return error
+func (t *token) checkRefresh() error {
+	if t.isStateless() {
+		return ErrStateless
+	}
+	if t.config.RefreshTimeout == 0 {
+		return ErrRefreshDisabled
+	}
+	return nil
+}
+
+// splicingKeyRefresh 拼接：保存 refresh token 时使用的key
+//
+//	@receiver t
+//	@param refreshToken string
+//	@return string
+func (t *token) splicingKeyRefresh(refreshToken string) string {
+	return t.config.TokenName + ":" + t.config.LoginType + ":refresh:" + refreshToken
+}
+
+// splicingKeyRefreshClaim 拼接：轮换 refresh token 时原子占用使用的key
+//
+//	@receiver t
+//	@param refreshToken string
+//	@return string
+func (t *token) splicingKeyRefreshClaim(refreshToken string) string {
+	return t.config.TokenName + ":" + t.config.LoginType + ":refresh-claim:" + refreshToken
+}
+
+// splicingKeyRefreshAccess 拼接：保存访问 token 所属令牌族时使用的key
+//
+//	@receiver t
+//	@param accessToken string
+//	@return string
+func (t *token) splicingKeyRefreshAccess(accessToken string) string {
+	return t.config.TokenName + ":" + t.config.LoginType + ":refresh-access:" + accessToken
+}
+
+// splicingKeyRefreshFamily 拼接：保存令牌族时使用的key
+//
+//	@receiver t
+//	@param familyId string
+//	@return string
+func (t *token) splicingKeyRefreshFamily(familyId string) string {
+	return t.config.TokenName + ":" + t.config.LoginType + ":refresh-family:" + familyId
+}
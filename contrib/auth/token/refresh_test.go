@@ -0,0 +1,263 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRefresh(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	defer repo.Close()
+
+	tk := New(WithRepository(repo), WithRefreshTimeout(3600))
+	pair, err := tk.LoginPair(ctx, 1001, LoginWithDevice("app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := tk.Refresh(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.AccessToken == pair.AccessToken || rotated.RefreshToken == pair.RefreshToken {
+		t.Fatal("refresh should rotate both tokens")
+	}
+	if _, err := tk.GetLoginId(ctx, pair.AccessToken); err == nil {
+		t.Fatal("old access token should be invalid after refresh")
+	}
+	if id, err := tk.GetLoginIdAsString(ctx, rotated.AccessToken); err != nil || id != "1001" {
+		t.Fatalf("loginId = %s, err = %v", id, err)
+	}
+	if signs, _ := tk.GetSignList(ctx, 1001, "app"); len(signs) != 1 {
+		t.Fatalf("signs = %d, want 1 on the original device", len(signs))
+	}
+
+	// 重复使用旧的 refresh token 会吊销整个令牌族
+	if _, err := tk.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("err = %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := tk.GetLoginId(ctx, rotated.AccessToken); err == nil {
+		t.Fatal("access token should be revoked after reuse")
+	}
+	if _, err := tk.Refresh(ctx, rotated.RefreshToken); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("err = %v, want ErrRefreshTokenInvalid", err)
+	}
+
+	if _, err := New(WithRepository(repo)).LoginPair(ctx, 1001); !errors.Is(err, ErrRefreshDisabled) {
+		t.Fatalf("err = %v, want ErrRefreshDisabled", err)
+	}
+}
+
+func TestRefreshConcurrentNodes(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	defer repo.Close()
+
+	// 两个节点共享同一个存储仓，同时使用同一个 refresh token
+	node1 := New(WithRepository(repo), WithRefreshTimeout(3600))
+	node2 := New(WithRepository(repo), WithRefreshTimeout(3600))
+	pair, err := node1.LoginPair(ctx, 1001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		wg      sync.WaitGroup
+		success int
+		reused  int
+		mu      sync.Mutex
+	)
+	for _, tk := range []Manager{node1, node2, node1, node2} {
+		wg.Add(1)
+		go func(tk Manager) {
+			defer wg.Done()
+			_, err := tk.Refresh(ctx, pair.RefreshToken)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				success++
+			case errors.Is(err, ErrRefreshTokenReused), errors.Is(err, ErrRefreshTokenInvalid):
+				reused++
+			default:
+				t.Error(err)
+			}
+		}(tk)
+	}
+	wg.Wait()
+	if success > 1 || success+reused != 4 {
+		t.Fatalf("success = %d, reused = %d, want at most one success", success, reused)
+	}
+}
+
+// failingRepository 写入 refresh token 时失败的存储仓
+type failingRepository struct {
+	*MemoryRepository
+	fail bool
+}
+
+func (r *failingRepository) Set(ctx context.Context, key string, value any, timeout time.Duration) error {
+	if r.fail && strings.Contains(key, ":refresh:") {
+		return errors.New("repository unavailable")
+	}
+	return r.MemoryRepository.Set(ctx, key, value, timeout)
+}
+
+func TestRefreshIssueFailure(t *testing.T) {
+	ctx := context.Background()
+	repo := &failingRepository{MemoryRepository: NewMemoryRepository()}
+	defer repo.Close()
+
+	tk := New(WithRepository(repo), WithRefreshTimeout(3600))
+	pair, err := tk.LoginPair(ctx, 1001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.fail = true
+	if _, err = tk.Refresh(ctx, pair.RefreshToken); err == nil {
+		t.Fatal("refresh should fail")
+	}
+	// 签发失败时旧的凭证仍然有效，可以重试
+	if _, err = tk.GetLoginId(ctx, pair.AccessToken); err != nil {
+		t.Fatalf("old access token should be valid, err = %v", err)
+	}
+	repo.fail = false
+	if _, err = tk.Refresh(ctx, pair.RefreshToken); err != nil {
+		t.Fatalf("retry refresh err = %v", err)
+	}
+}
+
+func TestRefreshRevoked(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	defer repo.Close()
+
+	tk := New(WithRepository(repo), WithRefreshTimeout(3600))
+	cases := map[string]func(pair *TokenPair) error{
+		"Logout": func(*TokenPair) error { return tk.Logout(ctx, 1001) },
+		"LogoutByTokenValue": func(pair *TokenPair) error {
+			return tk.LogoutByTokenValue(ctx, pair.AccessToken)
+		},
+		"KickOut": func(*TokenPair) error { return tk.KickOut(ctx, 1001, "app") },
+		"KickOutByTokenValue": func(pair *TokenPair) error {
+			return tk.KickOutByTokenValue(ctx, pair.AccessToken)
+		},
+	}
+	for name, revoke := range cases {
+		t.Run(name, func(t *testing.T) {
+			pair, err := tk.LoginPair(ctx, 1001, LoginWithDevice("app"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = revoke(pair); err != nil {
+				t.Fatal(err)
+			}
+			if _, err = tk.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrRefreshTokenInvalid) {
+				t.Fatalf("err = %v, want ErrRefreshTokenInvalid", err)
+			}
+		})
+	}
+}
+
+func TestRefreshKickedSession(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	defer repo.Close()
+
+	tk := New(WithRepository(repo), WithRefreshTimeout(3600))
+	pair, err := tk.LoginPair(ctx, 1001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 访问 token 和令牌族的映射丢失时，轮换前仍然会检查访问 token 的状态
+	if err = repo.Delete(ctx, tk.(*token).splicingKeyRefreshAccess(pair.AccessToken)); err != nil {
+		t.Fatal(err)
+	}
+	if err = tk.KickOutByTokenValue(ctx, pair.AccessToken); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tk.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("err = %v, want ErrRefreshTokenInvalid", err)
+	}
+	var nle *NotLoginError
+	if _, err = tk.GetLoginId(ctx, pair.AccessToken); !errors.As(err, &nle) || nle.GetType() != KickOut {
+		t.Fatalf("err = %v, want KickOut", err)
+	}
+
+	// 新登录挤掉旧登录时，轮换出的访问 token 顶掉旧的访问 token 不会吊销令牌族
+	tk = New(WithRepository(repo), WithRefreshTimeout(3600), WithIsConcurrent(false))
+	pair, err = tk.LoginPair(ctx, 1002)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := tk.Refresh(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tk.GetLoginId(ctx, rotated.AccessToken); err != nil {
+		t.Fatalf("rotated access token should be valid, err = %v", err)
+	}
+	if _, err = tk.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("err = %v, want ErrRefreshTokenReused", err)
+	}
+}
+
+func TestRefreshDisabled(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	defer repo.Close()
+
+	tk := New(WithRepository(repo), WithRefreshTimeout(3600))
+	pair, err := tk.LoginPair(ctx, 1001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tk.Disable(ctx, 1001, DefaultDisableService, 3600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tk.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrAccountDisabled) {
+		t.Fatalf("err = %v, want ErrAccountDisabled", err)
+	}
+}
+
+func TestRefreshFamilyLifetime(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	defer repo.Close()
+
+	tk := New(WithRepository(repo), WithRefreshTimeout(3600))
+	pair, err := tk.LoginPair(ctx, 1001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair.RefreshTimeout != 3600 {
+		t.Fatalf("refresh timeout = %d, want 3600", pair.RefreshTimeout)
+	}
+	// 令牌族即将过期，轮换不会延长有效期
+	record := &refreshRecord{}
+	if err = repo.Get(ctx, tk.(*token).splicingKeyRefresh(pair.RefreshToken), record); err != nil {
+		t.Fatal(err)
+	}
+	familyKey := tk.(*token).splicingKeyRefreshFamily(record.Family)
+	family := &refreshFamily{}
+	if err = repo.Get(ctx, familyKey, family); err != nil {
+		t.Fatal(err)
+	}
+	family.ExpireAt = time.Now().Unix() + 60
+	if err = repo.Set(ctx, familyKey, family, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := tk.Refresh(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.RefreshTimeout > 60 {
+		t.Fatalf("refresh timeout = %d, want at most 60", rotated.RefreshTimeout)
+	}
+	if ttl, _ := repo.TTL(ctx, familyKey); ttl > time.Minute {
+		t.Fatalf("family ttl = %s, want at most 1m", ttl)
+	}
+}
@@ -37,3 +37,18 @@ type Repository interface {
 	UpdateTTL(ctx context.Context, key string, ttl time.Duration) error
 	Delete(ctx context.Context, key string) error
 }
+
+// AtomicRepository 支持原子写入的存储仓，多个节点共享存储仓时 refresh token 的重复使用检测需要存储仓实现此接口，
+// 未实现时只在进程内保证同一个 refresh token 只能轮换一次
+type AtomicRepository interface {
+	Repository
+	// SetNX key 不存在时写入数据，返回是否写入成功
+	//
+	//  @param ctx context.Context
+	//  @param key string
+	//  @param value any
+	//  @param timeout time.Duration 有效期
+	//  @return bool 是否写入成功
+	//  @return error
+	SetNX(ctx context.Context, key string, value any, timeout time.Duration) (bool, error)
+}
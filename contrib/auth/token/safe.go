@@ -0,0 +1,165 @@
+// Package token
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package token
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// OpenSafe 为指定 token 开启二级认证，在 seconds 秒内访问敏感操作无需再次验证，
+// 开启前会校验 token 是否处于登录状态
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param tokenValue string token值
+//	@param service string 业务标识，为空时使用 DefaultSafeService
+//	@param seconds int64 二级认证有效期（单位：秒）
+//	@return error
+func (t *token) OpenSafe(ctx context.Context, tokenValue string, service string, seconds int64) error {
+	if t.isStateless() {
+		return ErrStateless
+	}
+	if seconds <= 0 {
+		return errors.New("safe time must be greater than 0")
+	}
+	if _, err := t.GetLoginId(ctx, tokenValue); err != nil {
+		return err
+	}
+	service = t.safeService(service)
+	if err := t.config.repository.Set(ctx, t.splicingKeySafe(tokenValue, service), SafeValue, time.Duration(seconds)*time.Second); err != nil {
+		return err
+	}
+	// 发布事件
+	t.config.listener.DoOpenSafe(t.config.LoginType, tokenValue, service, seconds)
+	return nil
+}
+
+// IsSafe 判断指定 token 是否处于二级认证有效期内
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param tokenValue string token值
+//	@param service string 业务标识，为空时使用 DefaultSafeService
+//	@return bool 是否已通过二级认证
+//	@return error
+func (t *token) IsSafe(ctx context.Context, tokenValue string, service string) (bool, error) {
+	if t.isStateless() {
+		return false, ErrStateless
+	}
+	if len(tokenValue) == 0 {
+		return false, nil
+	}
+	var value string
+	if err := t.config.repository.Get(ctx, t.splicingKeySafe(tokenValue, t.safeService(service)), &value); err != nil {
+		return false, err
+	}
+	return value == SafeValue, nil
+}
+
+// CheckSafe 校验指定 token 是否处于二级认证有效期内，不在有效期内时返回 NotSafeError
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param tokenValue string token值
+//	@param service string 业务标识，为空时使用 DefaultSafeService
+//	@return error
+func (t *token) CheckSafe(ctx context.Context, tokenValue string, service string) error {
+	safe, err := t.IsSafe(ctx, tokenValue, service)
+	if err != nil {
+		return err
+	}
+	if !safe {
+		return NewNotSafeError(t.config.LoginType, tokenValue, t.safeService(service))
+	}
+	return nil
+}
+
+// GetSafeTime 获取指定 token 二级认证的剩余有效期（单位：秒），未开启时返回 NotValueExpire
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param tokenValue string token值
+//	@param service string 业务标识，为空时使用 DefaultSafeService
+//	@return int64
+//	@return error
+func (t *token) GetSafeTime(ctx context.Context, tokenValue string, service string) (int64, error) {
+	if t.isStateless() {
+		return NotValueExpire, ErrStateless
+	}
+	if len(tokenValue) == 0 {
+		return NotValueExpire, nil
+	}
+	ttl, err := t.config.repository.TTL(ctx, t.splicingKeySafe(tokenValue, t.safeService(service)))
+	if err != nil {
+		return NotValueExpire, err
+	}
+	return int64(ttl.Seconds()), nil
+}
+
+// CloseSafe 关闭指定 token 的二级认证
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param tokenValue string token值
+//	@param service string 业务标识，为空时使用 DefaultSafeService
+//	@return error
+func (t *token) CloseSafe(ctx context.Context, tokenValue string, service string) error {
+	if t.isStateless() {
+		return ErrStateless
+	}
+	if len(tokenValue) == 0 {
+		return nil
+	}
+	service = t.safeService(service)
+	if err := t.config.repository.Delete(ctx, t.splicingKeySafe(tokenValue, service)); err != nil {
+		return err
+	}
+	// 发布事件
+	t.config.listener.DoCloseSafe(t.config.LoginType, tokenValue, service)
+	return nil
+}
+
+// safeService 获取二级认证业务标识，为空时使用默认值
+//
+//	@receiver t
+//	@param service string
+//	@return string
+func (t *token) safeService(service string) string {
+	if len(service) == 0 {
+		return DefaultSafeService
+	}
+	return service
+}
+
+// splicingKeySafe 拼接：保存二级认证标记时使用的key
+//
+//	@receiver t
+//	@param tokenValue string token值
+//	@param service string 业务标识
+//	@return string
+func (t *token) splicingKeySafe(tokenValue string, service string) string {
+	return t.config.TokenName + ":" + t.config.LoginType + ":safe:" + service + ":" + tokenValue
+}
@@ -0,0 +1,44 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSafe(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	defer repo.Close()
+
+	tk := New(WithRepository(repo))
+	tokenValue, err := tk.Login(ctx, 1001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nse *NotSafeError
+	if err := tk.CheckSafe(ctx, tokenValue, ""); !errors.As(err, &nse) || nse.GetService() != DefaultSafeService {
+		t.Fatalf("err = %v, want NotSafeError", err)
+	}
+	if err := tk.OpenSafe(ctx, tokenValue, "payment", 60); err != nil {
+		t.Fatal(err)
+	}
+	if err := tk.CheckSafe(ctx, tokenValue, "payment"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if safe, _ := tk.IsSafe(ctx, tokenValue, "password"); safe {
+		t.Fatal("safe state should be isolated by service")
+	}
+	if ttl, _ := tk.GetSafeTime(ctx, tokenValue, "payment"); ttl <= 0 || ttl > 60 {
+		t.Fatalf("safe time = %d, want (0, 60]", ttl)
+	}
+	if err := tk.CloseSafe(ctx, tokenValue, "payment"); err != nil {
+		t.Fatal(err)
+	}
+	if safe, _ := tk.IsSafe(ctx, tokenValue, "payment"); safe {
+		t.Fatal("safe state should be closed")
+	}
+	if err := tk.OpenSafe(ctx, "not-exist", "", 60); err == nil {
+		t.Fatal("open safe for invalid token should fail")
+	}
+}
@@ -1,61 +1,40 @@
 package token
 
 import (
-	"sync"
+	"context"
 	"testing"
 )
 
-func Test_session_AddTokenSign(t *testing.T) {
-	type fields struct {
-		serializeData *SerializeData
-		repository    Repository
-		lock          sync.RWMutex
+func Test_session_addTokenSign(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	defer repo.Close()
+
+	s := &session{
+		ID:       "session",
+		Data:     map[string]any{},
+		SignList: SignList{{Value: "tokenValue", Device: "test"}},
+		repo:     repo,
+	}
+	if err := repo.Set(ctx, s.ID, s, 0); err != nil {
+		t.Fatal(err)
+	}
+	// 相同 token 值覆盖设备信息
+	if err := s.addTokenSign(ctx, &Sign{Value: "tokenValue", Device: "deviceValue", Extra: map[string]interface{}{"test": "测试"}}); err != nil {
+		t.Fatal(err)
 	}
-	type args struct {
-		sign *Sign
+	if err := s.addTokenSign(ctx, &Sign{Value: "tokenValue2", Device: "pc"}); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := &session{}
+	if err := repo.Get(ctx, s.ID, saved); err != nil {
+		t.Fatal(err)
 	}
-	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		wantErr bool
-	}{
-		{
-			name: "test add token sign",
-			fields: fields{
-				serializeData: &SerializeData{
-					SignList: SignList{
-						{
-							Value:  "tokenValue",
-							Device: "test",
-						},
-					},
-				},
-				repository: nil,
-				lock:       sync.RWMutex{},
-			},
-			args: args{
-				sign: &Sign{
-					Value:  "tokenValue",
-					Device: "deviceValue",
-					Extra: map[string]interface{}{
-						"test": "测试",
-					},
-				},
-			},
-			wantErr: false,
-		},
+	if len(saved.SignList) != 2 || saved.SignList[0].Device != "deviceValue" || saved.SignList[1].Value != "tokenValue2" {
+		t.Fatalf("sign list = %+v", saved.SignList)
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			s := &session{
-				serializeData: tt.fields.serializeData,
-				repository:    tt.fields.repository,
-				lock:          tt.fields.lock,
-			}
-			if err := s.AddTokenSign(tt.args.sign); (err != nil) != tt.wantErr {
-				t.Errorf("addTokenSign() error = %v, wantErr %v", err, tt.wantErr)
-			}
-		})
+	if got := saved.getTokenValueListByDevice("pc"); len(got) != 1 || got[0] != "tokenValue2" {
+		t.Fatalf("pc tokens = %v", got)
 	}
 }
@@ -4,13 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/duke-git/lancet/v2/convertor"
 	set "github.com/duke-git/lancet/v2/datastructure/set"
 )
 
-var _ Token = (*token)(nil)
+var _ Manager = (*token)(nil)
 
 // Token token接口
 type Token interface {
@@ -115,16 +116,238 @@ type Token interface {
 	DisableLevel(ctx context.Context, loginId any, service string, level int, timeout int64) error
 }
 
+// Authenticator 认证中间件需要的 token 能力
+type Authenticator interface {
+	// GetTokenName 获取token名称，即请求头、cookie或查询参数中携带token的key
+	//
+	//  @return string
+	GetTokenName() string
+	// GetLoginId 获取登录账号，token 无效、过期、被顶下线、被踢下线或被冻结时返回 NotLoginError
+	//
+	//  @param ctx context.Context
+	//  @param tokenValue string token值
+	//  @return any 登录账号id
+	//  @return error 是否有错
+	GetLoginId(ctx context.Context, tokenValue string) (any, error)
+	// GetSessionByLoginId 获取指定用户id的 Account-Session
+	//
+	//  @param ctx context.Context
+	//  @param loginId any 登录id
+	//  @param isCreate bool 如果没有是否创建
+	//  @return Session session结构
+	//  @return error 是否有错
+	GetSessionByLoginId(ctx context.Context, loginId any, isCreate bool) (Session, error)
+}
+
+// Authorizer 角色和权限认证
+type Authorizer interface {
+	// GetRoles 获取账号拥有的角色
+	//
+	//  @param ctx context.Context
+	//  @param loginId any 登录账号id
+	//  @return []string 角色列表
+	//  @return error 是否有错
+	GetRoles(ctx context.Context, loginId any) ([]string, error)
+	// GetPermissions 获取账号拥有的权限码
+	//
+	//  @param ctx context.Context
+	//  @param loginId any 登录账号id
+	//  @return []string 权限码列表
+	//  @return error 是否有错
+	GetPermissions(ctx context.Context, loginId any) ([]string, error)
+	// HasRole 判断账号是否拥有指定角色
+	//
+	//  @param ctx context.Context
+	//  @param loginId any 登录账号id
+	//  @param role string 角色
+	//  @return bool 是否拥有
+	//  @return error 是否有错
+	HasRole(ctx context.Context, loginId any, role string) (bool, error)
+	// HasPermission 判断账号是否拥有指定权限，支持通配符
+	//
+	//  @param ctx context.Context
+	//  @param loginId any 登录账号id
+	//  @param permission string 权限码
+	//  @return bool 是否拥有
+	//  @return error 是否有错
+	HasPermission(ctx context.Context, loginId any, permission string) (bool, error)
+	// CheckRole 校验账号是否拥有指定角色，不满足时返回 NotRoleError
+	//
+	//  @param ctx context.Context
+	//  @param loginId any 登录账号id
+	//  @param mode Mode 校验模式
+	//  @param roles ...string 角色列表
+	//  @return error
+	CheckRole(ctx context.Context, loginId any, mode Mode, roles ...string) error
+	// CheckPermission 校验账号是否拥有指定权限，不满足时返回 NotPermissionError
+	//
+	//  @param ctx context.Context
+	//  @param loginId any 登录账号id
+	//  @param mode Mode 校验模式
+	//  @param permissions ...string 权限码列表
+	//  @return error
+	CheckPermission(ctx context.Context, loginId any, mode Mode, permissions ...string) error
+	// ClearPermissionCache 清除账号缓存的角色和权限
+	//
+	//  @param ctx context.Context
+	//  @param loginId any 登录账号id
+	//  @return error
+	ClearPermissionCache(ctx context.Context, loginId any) error
+}
+
+// SafeAuthenticator 二级认证
+type SafeAuthenticator interface {
+	// OpenSafe 为指定 token 开启二级认证
+	//
+	//  @param ctx context.Context
+	//  @param tokenValue string token值
+	//  @param service string 业务标识，为空时使用 DefaultSafeService
+	//  @param seconds int64 二级认证有效期（单位：秒）
+	//  @return error
+	OpenSafe(ctx context.Context, tokenValue string, service string, seconds int64) error
+	// IsSafe 判断指定 token 是否处于二级认证有效期内
+	//
+	//  @param ctx context.Context
+	//  @param tokenValue string token值
+	//  @param service string 业务标识，为空时使用 DefaultSafeService
+	//  @return bool 是否已通过二级认证
+	//  @return error
+	IsSafe(ctx context.Context, tokenValue string, service string) (bool, error)
+	// CheckSafe 校验指定 token 是否处于二级认证有效期内，不在有效期内时返回 NotSafeError
+	//
+	//  @param ctx context.Context
+	//  @param tokenValue string token值
+	//  @param service string 业务标识，为空时使用 DefaultSafeService
+	//  @return error
+	CheckSafe(ctx context.Context, tokenValue string, service string) error
+	// GetSafeTime 获取指定 token 二级认证的剩余有效期（单位：秒），未开启时返回 NotValueExpire
+	//
+	//  @param ctx context.Context
+	//  @param tokenValue string token值
+	//  @param service string 业务标识，为空时使用 DefaultSafeService
+	//  @return int64
+	//  @return error
+	GetSafeTime(ctx context.Context, tokenValue string, service string) (int64, error)
+	// CloseSafe 关闭指定 token 的二级认证
+	//
+	//  @param ctx context.Context
+	//  @param tokenValue string token值
+	//  @param service string 业务标识，为空时使用 DefaultSafeService
+	//  @return error
+	CloseSafe(ctx context.Context, tokenValue string, service string) error
+}
+
+// OnlineManager 在线会话查询和踢人下线
+type OnlineManager interface {
+	// KickOut 踢人下线，根据账号id 和 设备类型
+	//
+	//  @param ctx context.Context
+	//  @param loginId any 登录账号id
+	//  @param device string 设备类型，为空时踢下所有设备
+	//  @return error
+	KickOut(ctx context.Context, loginId any, device string) error
+	// KickOutByTokenValue 踢人下线，根据 token 值
+	//
+	//  @param ctx context.Context
+	//  @param tokenValue string token值
+	//  @return error
+	KickOutByTokenValue(ctx context.Context, tokenValue string) error
+	// SearchTokenValue 搜索在线的 token 值，需要存储仓实现 SearchRepository
+	//
+	//  @param ctx context.Context
+	//  @param keyword string 关键字，为空时不过滤
+	//  @param start int 开始下标
+	//  @param size int 获取数量，-1 代表到末尾
+	//  @param sortAsc bool 是否升序
+	//  @return []string token值列表
+	//  @return error
+	SearchTokenValue(ctx context.Context, keyword string, start, size int, sortAsc bool) ([]string, error)
+	// SearchSessionId 搜索 Account-Session 的 id，需要存储仓实现 SearchRepository
+	//
+	//  @param ctx context.Context
+	//  @param keyword string 关键字，为空时不过滤
+	//  @param start int 开始下标
+	//  @param size int 获取数量，-1 代表到末尾
+	//  @param sortAsc bool 是否升序
+	//  @return []string 登录账号id列表
+	//  @return error
+	SearchSessionId(ctx context.Context, keyword string, start, size int, sortAsc bool) ([]string, error)
+	// SearchTokenSessionId 搜索 Token-Session 的 id，需要存储仓实现 SearchRepository
+	//
+	//  @param ctx context.Context
+	//  @param keyword string 关键字，为空时不过滤
+	//  @param start int 开始下标
+	//  @param size int 获取数量，-1 代表到末尾
+	//  @param sortAsc bool 是否升序
+	//  @return []string token值列表
+	//  @return error
+	SearchTokenSessionId(ctx context.Context, keyword string, start, size int, sortAsc bool) ([]string, error)
+	// GetSignList 获取账号在指定设备上的登录签名，device 为空时返回所有设备
+	//
+	//  @param ctx context.Context
+	//  @param loginId any 登录账号id
+	//  @param device string 设备类型
+	//  @return SignList 签名列表
+	//  @return error
+	GetSignList(ctx context.Context, loginId any, device string) (SignList, error)
+	// GetTokenTimeout 获取 token 剩余有效期（单位：秒），永不过期返回 NeverExpire，不存在返回 NotValueExpire
+	//
+	//  @param ctx context.Context
+	//  @param tokenValue string token值
+	//  @return int64
+	//  @return error
+	GetTokenTimeout(ctx context.Context, tokenValue string) (int64, error)
+}
+
+// Refresher refresh token 签发和轮换，需要配置 RefreshTimeout
+type Refresher interface {
+	// LoginPair 登录并签发访问 token 和 refresh token，需要配置 RefreshTimeout
+	//
+	//  @param ctx context.Context
+	//  @param loginId any 推荐使用（int64，int，string）类型
+	//  @param opts ...LoginOption 登录参数
+	//  @return *TokenPair
+	//  @return error
+	LoginPair(ctx context.Context, loginId any, opts ...LoginOption) (*TokenPair, error)
+	// Refresh 使用 refresh token 轮换出新的访问 token 和 refresh token，重复使用时吊销整个令牌族并返回 ErrRefreshTokenReused，
+	// 访问 token 已注销、被踢下线或顶下线时返回 ErrRefreshTokenInvalid，账号被封禁时返回 ErrAccountDisabled
+	//
+	//  @param ctx context.Context
+	//  @param refreshToken string refresh token
+	//  @return *TokenPair
+	//  @return error
+	Refresh(ctx context.Context, refreshToken string) (*TokenPair, error)
+	// RevokeRefreshToken 吊销 refresh token 所在的整个令牌族，并注销当前的访问 token
+	//
+	//  @param ctx context.Context
+	//  @param refreshToken string refresh token
+	//  @return error
+	RevokeRefreshToken(ctx context.Context, refreshToken string) error
+}
+
+// Manager token 的全部能力，New 创建的实例实现此接口，
+// 新增的能力通过独立的接口提供，实现 Token 接口的自定义类型不受影响
+type Manager interface {
+	Token
+	Authenticator
+	Authorizer
+	SafeAuthenticator
+	OnlineManager
+	Refresher
+}
+
 // token 实例
 type token struct {
-	config *Config
+	config    *Config
+	jwt       *jwtCodec
+	refreshMu sync.Mutex // 存储仓不支持原子操作时串行化 refresh token 轮换
 }
 
 // New create token with option
 //
 //	@param config ...Option 创建参数
-//	@return Token 实例
-func New(opts ...Option) Token {
+//	@return Manager 实例
+func New(opts ...Option) Manager {
 	conf := DefaultConfig()
 	for _, opt := range opts {
 		opt(conf)
@@ -133,12 +356,20 @@ func New(opts ...Option) Token {
 }
 
 // NewWithConfig create token with config
-func NewWithConfig(configs ...*Config) Token {
+func NewWithConfig(configs ...*Config) Manager {
 	conf := DefaultConfig()
 	if len(configs) > 0 {
 		conf = configs[0]
 	}
-	return &token{conf}
+	t := &token{config: conf}
+	if conf.Style == StyleJWT {
+		keys := conf.jwtKeys
+		if len(conf.JWTSecret) > 0 {
+			keys = append(keys, NewHS256Key("", []byte(conf.JWTSecret)))
+		}
+		t.jwt = newJWTCodec(keys)
+	}
+	return t
 }
 
 // Login 登录方法
@@ -162,6 +393,15 @@ func (t *token) Login(ctx context.Context, loginId any, opts ...LoginOption) (st
 	}
 	// 3.补充参数
 	o.Apply(t.config)
+	// 无状态 jwt 直接签发，不访问 Repository
+	if t.isStateless() {
+		tokenValue, err := t.createJWT(loginId, o)
+		if err != nil {
+			return "", err
+		}
+		t.config.listener.DoLogin(t.config.LoginType, loginId, tokenValue, o)
+		return tokenValue, nil
+	}
 	// 4.分配一个可用的token
 	tokenValue, err := t.distUsableToken(ctx, loginId, o)
 	if err != nil {
@@ -222,6 +462,9 @@ func (t *token) Logout(ctx context.Context, loginId any) error {
 //	@param device string 登陆设备
 //	@return error
 func (t *token) LogoutByDevice(ctx context.Context, loginId any, device string) error {
+	if t.isStateless() {
+		return ErrStateless
+	}
 	ss, err := t.getSessionByLoginId(ctx, loginId, false)
 	if err != nil {
 		return err
@@ -242,17 +485,25 @@ func (t *token) LogoutByDevice(ctx context.Context, loginId any, device string)
 				}
 			}
 
-			// 2.3、清除 token -> id 的映射关系
+			// 2.3、清除 token -> id 的映射关系，jwt 写入黑名单
 			if err := t.deleteTokenToIdMapping(tokenValue); err != nil {
 				return err
 			}
+			if err := t.denyJWT(ctx, tokenValue, InvalidToken); err != nil {
+				return err
+			}
 
 			// 2.4、清除这个 token 的 Token-Session 对象
 			if err := t.deleteTokenSession(ctx, tokenValue); err != nil {
 				return err
 			}
 
-			// 2.5、$$ 发布事件：xx 账号的 xx 客户端注销了
+			// 2.5、吊销这个 token 对应的 refresh token 令牌族
+			if err := t.revokeRefreshByAccessToken(ctx, tokenValue); err != nil {
+				return err
+			}
+
+			// 2.6、$$ 发布事件：xx 账号的 xx 客户端注销了
 			t.config.listener.DoLogout(t.config.LoginType, loginId, tokenValue)
 		}
 		// 3、如果代码走到这里的时候，此账号已经没有客户端在登录了，则直接注销掉这个 Account-Session
@@ -274,6 +525,9 @@ func (t *token) LogoutByTokenValue(ctx context.Context, tokenValue string) error
 	if len(tokenValue) == 0 {
 		return nil
 	}
+	if t.isStateless() {
+		return ErrStateless
+	}
 	// 1、清除这个 token 的最后活跃时间记录
 	if t.isOpenCheckActiveTimeout() {
 		err := t.clearLastActive(tokenValue)
@@ -298,6 +552,12 @@ func (t *token) LogoutByTokenValue(ctx context.Context, tokenValue string) error
 			return err
 		}
 	}
+	if err := t.denyJWT(ctx, tokenValue, InvalidToken); err != nil {
+		return err
+	}
+	if err := t.revokeRefreshByAccessToken(ctx, tokenValue); err != nil {
+		return err
+	}
 
 	// 4、判断一下：如果此 token 映射的是一个无效 loginId，则此处立即返回，不需要再往下处理了
 	if !t.isValidLoginId(loginId) {
@@ -327,6 +587,9 @@ func (t *token) LogoutByTokenValue(ctx context.Context, tokenValue string) error
 
 // Replaced 顶人下线，根据账号id 和 设备类型 ，当用户顶下线后，再次访问则会返回
 func (t *token) Replaced(ctx context.Context, loginId any, device string) error {
+	if t.isStateless() {
+		return ErrStateless
+	}
 	ss, err := t.getSessionByLoginId(ctx, loginId, false)
 	if err != nil {
 		return err
@@ -334,24 +597,12 @@ func (t *token) Replaced(ctx context.Context, loginId any, device string) error
 	if ss != nil {
 		for _, sign := range ss.getTokenSignListByDevice(device) {
 			tokenValue := sign.Value
-			// 2.1、从 Account-Session 上清除 token 签名
-			if err := ss.removeTokenSign(ctx, tokenValue); err != nil {
-				return err
-			}
-
-			// 2.2、清除这个 token 的最后活跃时间记录
-			if t.isOpenCheckActiveTimeout() {
-				if err := t.clearLastActive(tokenValue); err != nil {
-					return err
-				}
-			}
-
-			// 2.3 将此 token 标记为：已被顶下线
-			if err := t.updateTokenToIdMapping(ctx, tokenValue, BeReplaced); err != nil {
+			// 2.1、清除 token 签名和最后活跃时间，并将此 token 标记为：已被顶下线
+			if err := t.offline(ctx, ss, tokenValue, BeReplaced); err != nil {
 				return err
 			}
 
-			// 2.4、发布事件：xx 账号的 xx 客户端注销了
+			// 2.2、发布事件：xx 账号的 xx 客户端注销了
 			t.config.listener.DoReplaced(t.config.LoginType, loginId, tokenValue)
 		}
 	}
@@ -383,6 +634,9 @@ func (t *token) IsLogin(ctx context.Context, tokenValue string) (bool, error) {
 //	@return bool 是否登录
 //	@return error 是否有错
 func (t *token) IsLoginByLoginId(ctx context.Context, loginId any) (bool, error) {
+	if t.isStateless() {
+		return false, ErrStateless
+	}
 	tokenValues, err := t.getTokenValueByLoginId(ctx, loginId, "")
 	if err != nil {
 		return false, err
@@ -410,13 +664,16 @@ func (t *token) GetLoginIdAsString(ctx context.Context, tokenValue string) (stri
 //	@return any 登录id
 //	@return error 是否有错
 func (t *token) GetLoginId(ctx context.Context, tokenValue string) (any, error) {
-	// 1、查询登录id
-	loginId, err := t.getLoginIdDefaultNil(ctx, tokenValue)
+	// 1、查询登录id，这里不过滤异常标记值，以便区分 token 的具体状态
+	if len(tokenValue) == 0 {
+		return nil, NewNotLoginError(InvalidToken, t.config.LoginType, InvalidTokenMessage)
+	}
+	loginId, err := t.getLoginIdNotHandle(ctx, tokenValue)
 	if err != nil {
 		return nil, err
 	}
-	// 2、如果loginId不存在，则表示获取了无效的token
-	if loginId == nil {
+	// 2、如果loginId不存在或被标记为无效，则表示获取了无效的token
+	if len(loginId) == 0 || loginId == InvalidToken {
 		return nil, NewNotLoginError(InvalidToken, t.config.LoginType, InvalidTokenMessage, tokenValue)
 	}
 	// 3、如果是token已过期
@@ -446,6 +703,14 @@ func (t *token) GetLoginId(ctx context.Context, tokenValue string) (any, error)
 	return loginId, nil
 }
 
+// GetTokenName 获取token名称
+//
+//	@receiver t
+//	@return string
+func (t *token) GetTokenName() string {
+	return t.config.TokenName
+}
+
 // GetLoginIdAsInt 获取登录账号数字
 //
 //	@receiver t
@@ -487,6 +752,9 @@ func (t *token) Disable(ctx context.Context, loginId any, service string, timeou
 //		@param timeout int64 封禁时间, 单位: 秒 （-1=永久封禁）
 //		@return error
 func (t *token) DisableLevel(ctx context.Context, loginId any, service string, level int, timeout int64) error {
+	if t.isStateless() {
+		return ErrStateless
+	}
 	if len(convertor.ToString(loginId)) == 0 {
 		return errors.New("loginId is empty")
 	}
@@ -529,6 +797,10 @@ func (t *token) checkLoginArgs(loginId any, opts LoginOptions) error {
 	if !t.config.DynamicActiveTimeout && opts.activeTimeout > 0 {
 		t.config.logger.Warn("当前全局配置未开启动态 activeTimeout 功能，传入的 activeTimeout 参数将被忽略")
 	}
+	// 5.jwt 必须配置密钥
+	if t.isJWT() && len(t.jwt.keys) == 0 {
+		return errors.New("jwt 密钥未配置")
+	}
 	return nil
 }
 
@@ -567,6 +839,21 @@ func (t *token) distUsableToken(ctx context.Context, loginId any, opts LoginOpti
 		}
 	}
 	// 4、如果代码走到此处，说明未能成功复用旧 token，需要根据算法新建 token
+	return t.newTokenValue(ctx, loginId, opts)
+}
+
+// newTokenValue 根据算法新建 token，jwt 自带唯一标识，无需检查
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param loginId any
+//	@param opts LoginOptions
+//	@return string
+//	@return error
+func (t *token) newTokenValue(ctx context.Context, loginId any, opts LoginOptions) (string, error) {
+	if t.isJWT() {
+		return t.createJWT(loginId, opts)
+	}
 	return t.config.generateUniqueToken(
 		"token",
 		t.config.MaxTryCount,
@@ -653,11 +940,15 @@ func (t *token) logoutByMaxLoginCount(ctx context.Context, loginId any, ss *sess
 				return err
 			}
 		}
-		// 3.3 删除 token - id 映射
+		// 3.3 删除 token - id 映射，jwt 写入黑名单
 		err = t.deleteTokenToIdMapping(tokenValue)
 		if err != nil {
 			return err
 		}
+		err = t.denyJWT(ctx, tokenValue, InvalidToken)
+		if err != nil {
+			return err
+		}
 		// 3.4、清除这个 token 的 Token-Session 对象
 		err = t.deleteTokenSession(ctx, tokenValue)
 		if err != nil {
@@ -698,6 +989,9 @@ func (t *token) isValidLoginId(loginId any) bool {
 //	@receiver t
 //	@return bool
 func (t *token) isOpenCheckActiveTimeout() bool {
+	if t.isStateless() {
+		return false
+	}
 	if t.config.DynamicActiveTimeout || t.config.ActiveTimeout != NeverExpire {
 		return true
 	}
@@ -870,7 +1164,14 @@ func (t *token) getTokenSignListByLoginId(ctx context.Context, loginId any, devi
 //	@return Session session结构体
 //	@return error 是否有错
 func (t *token) GetSessionByLoginId(ctx context.Context, loginId any, isCrete bool) (Session, error) {
-	return t.getSessionByLoginId(ctx, loginId, isCrete)
+	if t.isStateless() {
+		return nil, ErrStateless
+	}
+	ss, err := t.getSessionByLoginId(ctx, loginId, isCrete)
+	if err != nil || ss == nil {
+		return nil, err
+	}
+	return ss, nil
 }
 
 // GetSessionByLoginIdDefault 获取指定用户id的 Account-session，如果没有则创建一个
@@ -881,6 +1182,9 @@ func (t *token) GetSessionByLoginId(ctx context.Context, loginId any, isCrete bo
 //	@return Session session结构
 //	@return error 是否有错
 func (t *token) GetSessionByLoginIdDefault(ctx context.Context, loginId any) (Session, error) {
+	if t.isStateless() {
+		return nil, ErrStateless
+	}
 	return t.getSessionByLoginId(ctx, loginId, true)
 }
 
@@ -966,6 +1270,9 @@ func (t *token) GetTokenSessionByTokenValue(ctx context.Context, tokenValue stri
 	if len(tokenValue) == 0 {
 		return nil, errors.New("Token-Session 获取失败：token 不能为空")
 	}
+	if t.isStateless() {
+		return nil, ErrStateless
+	}
 	return t.getSessionBySessionId(ctx, t.splicingKeyTokenSession(tokenValue), isCreate, func(s *session) error {
 		s.SessionType = TokenSessionType
 		s.LoginType = t.config.LoginType
@@ -995,6 +1302,9 @@ func (t *token) createTokenValue(loginId any, device string, timeout int64, extr
 //	@return error
 //	@player
 func (t *token) getLoginIdNotHandle(ctx context.Context, tokenValue string) (string, error) {
+	if t.isJWT() {
+		return t.getLoginIdByJWT(ctx, tokenValue)
+	}
 	var loginId string
 	if err := t.config.repository.Get(ctx, t.splicingKeyTokenValue(tokenValue), &loginId); err != nil {
 		return "", err
@@ -1025,7 +1335,7 @@ func (t *token) updateTokenToIdMapping(ctx context.Context, tokenValue string, l
 	if len(convertor.ToString(loginId)) == 0 {
 		return errors.New("loginId 不能为空")
 	}
-	return t.config.repository.Update(ctx, tokenValue, convertor.ToString(loginId))
+	return t.config.repository.Update(ctx, t.splicingKeyTokenValue(tokenValue), convertor.ToString(loginId))
 }
 
 // setLastActiveToNow 设置token的最后活跃时间为当前时间
@@ -1118,6 +1428,15 @@ func (t *token) splicingKeyLastActiveTime(tokenValue string) string {
 	return t.config.TokenName + ":" + t.config.LoginType + ":last-active:" + tokenValue
 }
 
+// splicingKeyJWTDeny 拼接：jwt 黑名单使用的key
+//
+//	@receiver t
+//	@param jti string jwt唯一标识
+//	@return string
+func (t *token) splicingKeyJWTDeny(jti string) string {
+	return t.config.TokenName + ":" + t.config.LoginType + ":jwt-deny:" + jti
+}
+
 // splicingKeyDisable 拼接key ，存储封禁信息的key
 //
 //	@receiver t
@@ -1127,3 +1446,76 @@ func (t *token) splicingKeyLastActiveTime(tokenValue string) string {
 func (t *token) splicingKeyDisable(loginId any, service string) string {
 	return t.config.TokenName + ":" + t.config.LoginType + ":disable:" + service + ":" + convertor.ToString(loginId)
 }
+
+// isJWT 是否使用 jwt
+//
+//	@receiver t
+//	@return bool
+func (t *token) isJWT() bool {
+	return t.jwt != nil
+}
+
+// isStateless 是否是无状态 jwt
+//
+//	@receiver t
+//	@return bool
+func (t *token) isStateless() bool {
+	return t.isJWT() && t.config.JWTMode == JWTModeStateless
+}
+
+// createJWT 签发 jwt
+//
+//	@receiver t
+//	@param loginId any 登录账号
+//	@param opts LoginOptions 登录参数
+//	@return string token值
+//	@return error
+func (t *token) createJWT(loginId any, opts LoginOptions) (string, error) {
+	return t.jwt.encode(newClaims(convertor.ToString(loginId), t.config.LoginType, opts.GetDevice(), opts.GetTimeout(), opts.GetExtraData()))
+}
+
+// getLoginIdByJWT 校验 jwt 并获取登录账号，过期返回 TimeoutToken，在黑名单中返回拉黑时的异常标记，无效返回空字符串
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param tokenValue string token值
+//	@return string 登录账号
+//	@return error
+func (t *token) getLoginIdByJWT(ctx context.Context, tokenValue string) (string, error) {
+	claims, err := t.jwt.decode(tokenValue)
+	if errors.Is(err, errJWTExpired) {
+		return TimeoutToken, nil
+	}
+	if err != nil || claims.LoginType != t.config.LoginType {
+		return "", nil
+	}
+	if !t.isStateless() {
+		var marker string
+		if err := t.config.repository.Get(ctx, t.splicingKeyJWTDeny(claims.ID), &marker); err != nil {
+			return "", err
+		}
+		if len(marker) > 0 {
+			return marker, nil
+		}
+	}
+	return claims.Subject, nil
+}
+
+// denyJWT 把 jwt 加入黑名单，直到 jwt 过期，非混合模式 jwt 不做处理
+//
+//	@receiver t
+//	@param ctx context.Context
+//	@param tokenValue string token值
+//	@param marker string 异常标记，例如 BeReplaced
+//	@return error
+func (t *token) denyJWT(ctx context.Context, tokenValue string, marker string) error {
+	if !t.isJWT() || t.isStateless() {
+		return nil
+	}
+	claims, err := t.jwt.decode(tokenValue)
+	if err != nil {
+		// 无效或已过期的 token 无需拉黑
+		return nil
+	}
+	return t.config.repository.Set(ctx, t.splicingKeyJWTDeny(claims.ID), marker, claims.ttl())
+}
@@ -26,6 +26,8 @@ package redis
 import (
 	"context"
 	"errors"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -86,6 +88,16 @@ func (i *impl) Set(ctx context.Context, key string, value any, timeout time.Dura
 	return i.client.Set(ctx, key, string(marshal), timeout).Err()
 }
 
+// SetNX key 不存在时写入数据，返回是否写入成功
+func (i *impl) SetNX(ctx context.Context, key string, value any, timeout time.Duration) (bool, error) {
+	key = i.getStoreKey(key)
+	marshal, err := cache.DefaultSerializer().Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	return i.client.SetNX(ctx, key, string(marshal), timeout).Result()
+}
+
 func (i *impl) Update(ctx context.Context, key string, value any) error {
 	ttl, err := i.TTL(ctx, key)
 	if err != nil {
@@ -109,6 +121,57 @@ func (i *impl) Delete(ctx context.Context, key string) error {
 	return i.client.Del(ctx, key).Err()
 }
 
+// Search 使用 SCAN 搜索以 prefix 开头并包含 keyword 的 key，返回的 key 不包含配置的前缀
+//
+//	@receiver i
+//	@param ctx context.Context
+//	@param prefix string key前缀
+//	@param keyword string 关键字，为空时不过滤
+//	@param start int 开始下标
+//	@param size int 获取数量，-1 代表到末尾
+//	@param sortAsc bool 是否按 key 升序
+//	@return []string
+//	@return error
+func (i *impl) Search(ctx context.Context, prefix, keyword string, start, size int, sortAsc bool) ([]string, error) {
+	storePrefix := i.getStoreKey("")
+	// prefix 和 keyword 中的 glob 字符按字面匹配
+	match := escapeGlob(i.getStoreKey(prefix)) + "*"
+	if keyword != "" {
+		match = escapeGlob(i.getStoreKey(prefix)) + "*" + escapeGlob(keyword) + "*"
+	}
+	// SCAN 可能返回重复的 key，需要去重
+	seen := make(map[string]struct{})
+	keys := make([]string, 0)
+	iter := i.client.Scan(ctx, 0, match, 1000).Iterator()
+	for iter.Next(ctx) {
+		key := strings.TrimPrefix(iter.Val(), storePrefix)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		keys = append(keys, key)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	if sortAsc {
+		sort.Strings(keys)
+	} else {
+		sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+	}
+	if start < 0 {
+		start = 0
+	}
+	if start >= len(keys) {
+		return []string{}, nil
+	}
+	end := len(keys)
+	if size >= 0 && start+size < end {
+		end = start + size
+	}
+	return keys[start:end], nil
+}
+
 //	func (c *impl) Get(ctx context.Context, key string) (string, error) {
 //		key = c.getStoreKey(key)
 //		return c.client.Get(ctx, key).Scan(v)
@@ -154,6 +217,14 @@ func (i *impl) Delete(ctx context.Context, key string) error {
 //		}
 //		return c.client.Set(ctx, key, storeBytes, timeout).Err()
 //	}
+
+// escapeGlob 转义 SCAN MATCH 中的 glob 字符
+func escapeGlob(s string) string {
+	return globReplacer.Replace(s)
+}
+
+var globReplacer = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
 func (i *impl) getStoreKey(key string) string {
 	if i.config.Prefix != "" {
 		return i.config.Prefix + ":" + key
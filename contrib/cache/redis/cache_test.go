@@ -65,3 +65,9 @@ func TestCache(t *testing.T) {
 	}
 
 }
+
+func TestEscapeGlob(t *testing.T) {
+	if got := escapeGlob(`token:*?[a]\`); got != `token:\*\?\[a\]\\` {
+		t.Fatalf("escapeGlob = %s", got)
+	}
+}
@@ -0,0 +1,181 @@
+// Package redis
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/go-fox/fox/transport/websocket"
+)
+
+const (
+	// DefaultBrokerChannel 默认的 websocket 推送频道
+	DefaultBrokerChannel = "fox:websocket:broker"
+
+	defaultMinBackoff = 100 * time.Millisecond
+	defaultMaxBackoff = 10 * time.Second
+)
+
+var _ websocket.Broker = (*Broker)(nil)
+
+// BrokerOption redis broker option
+type BrokerOption func(b *Broker)
+
+// BrokerWithChannel with a pub/sub channel option
+func BrokerWithChannel(channel string) BrokerOption {
+	return func(b *Broker) {
+		b.channel = channel
+	}
+}
+
+// BrokerWithLogger with a logger option
+func BrokerWithLogger(logger *slog.Logger) BrokerOption {
+	return func(b *Broker) {
+		b.logger = logger
+	}
+}
+
+// BrokerWithBackoff with the resubscribe backoff option, the interval doubles from minBackoff up to maxBackoff after each failure
+func BrokerWithBackoff(minBackoff, maxBackoff time.Duration) BrokerOption {
+	return func(b *Broker) {
+		b.minBackoff = minBackoff
+		b.maxBackoff = maxBackoff
+	}
+}
+
+// Broker 基于 redis pub/sub 的 websocket 消息总线
+type Broker struct {
+	client     Client
+	channel    string
+	logger     *slog.Logger
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// NewBroker create a websocket broker on redis client
+func NewBroker(client Client, opts ...BrokerOption) *Broker {
+	b := &Broker{
+		client:     client,
+		channel:    DefaultBrokerChannel,
+		logger:     slog.With(slog.String("mod", "clients.redis.broker")),
+		minBackoff: defaultMinBackoff,
+		maxBackoff: defaultMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Publish 发布消息到频道
+func (b *Broker) Publish(ctx context.Context, msg *websocket.BrokerMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, b.channel, data).Err()
+}
+
+// Subscribe 订阅频道，订阅确认后返回，订阅断开时按退避间隔重新订阅，ctx 结束时取消订阅
+func (b *Broker) Subscribe(ctx context.Context, handler websocket.BrokerHandler) error {
+	ps, err := b.subscribe(ctx)
+	if err != nil {
+		return err
+	}
+	go b.run(ctx, ps, handler)
+	return nil
+}
+
+// subscribe 订阅频道并等待订阅确认
+func (b *Broker) subscribe(ctx context.Context) (*redis.PubSub, error) {
+	ps := b.client.Subscribe(ctx, b.channel)
+	if _, err := ps.Receive(ctx); err != nil {
+		_ = ps.Close()
+		return nil, err
+	}
+	return ps, nil
+}
+
+// run 接收消息，订阅断开后重新订阅，直到 ctx 结束
+func (b *Broker) run(ctx context.Context, ps *redis.PubSub, handler websocket.BrokerHandler) {
+	for {
+		b.consume(ctx, ps, handler)
+		_ = ps.Close()
+		if ctx.Err() != nil {
+			return
+		}
+		b.logger.Warn("[Broker] subscription closed, resubscribing", "channel", b.channel)
+		if ps = b.resubscribe(ctx); ps == nil {
+			return
+		}
+	}
+}
+
+// consume 接收消息直到 ctx 结束或订阅断开
+func (b *Broker) consume(ctx context.Context, ps *redis.PubSub, handler websocket.BrokerHandler) {
+	ch := ps.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m, ok := <-ch:
+			if !ok {
+				return
+			}
+			msg := &websocket.BrokerMessage{}
+			if err := json.Unmarshal([]byte(m.Payload), msg); err != nil {
+				b.logger.Error("[Broker] unmarshal message error", "error", err)
+				continue
+			}
+			handler(msg)
+		}
+	}
+}
+
+// resubscribe 按退避间隔重新订阅，ctx 结束时返回 nil
+func (b *Broker) resubscribe(ctx context.Context) *redis.PubSub {
+	backoff := b.minBackoff
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+		}
+		ps, err := b.subscribe(ctx)
+		if err == nil {
+			b.logger.Info("[Broker] resubscribed", "channel", b.channel)
+			return ps
+		}
+		b.logger.Error("[Broker] resubscribe error", "channel", b.channel, "backoff", backoff, "error", err)
+		backoff = min(backoff*2, b.maxBackoff)
+		timer.Reset(backoff)
+	}
+}
@@ -0,0 +1,83 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/go-fox/fox/transport/websocket"
+)
+
+func newTestBroker(t *testing.T) *Broker {
+	s := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+	return NewBroker(client, BrokerWithBackoff(10*time.Millisecond, 50*time.Millisecond))
+}
+
+func receive(t *testing.T, ch <-chan *websocket.BrokerMessage) *websocket.BrokerMessage {
+	t.Helper()
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("receive timeout")
+	}
+	return nil
+}
+
+func TestBroker(t *testing.T) {
+	b := newTestBroker(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan *websocket.BrokerMessage, 1)
+	if err := b.Subscribe(ctx, func(msg *websocket.BrokerMessage) {
+		ch <- msg
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Publish(ctx, &websocket.BrokerMessage{Room: "room", Data: []byte("hello")}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := receive(t, ch); msg.Room != "room" || string(msg.Data) != "hello" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+}
+
+func TestBrokerResubscribe(t *testing.T) {
+	b := newTestBroker(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ps, err := b.subscribe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan *websocket.BrokerMessage, 1)
+	go b.run(ctx, ps, func(msg *websocket.BrokerMessage) {
+		ch <- msg
+	})
+	// 订阅断开后重新订阅，之后发布的消息仍能收到
+	_ = ps.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if err = b.Publish(ctx, &websocket.BrokerMessage{SessionId: "1"}); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case msg := <-ch:
+			if msg.SessionId != "1" {
+				t.Fatalf("unexpected message: %+v", msg)
+			}
+			return
+		case <-time.After(20 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("resubscribe timeout")
+		}
+	}
+}
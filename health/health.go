@@ -0,0 +1,272 @@
+// Package health
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Status 健康状态
+type Status int
+
+const (
+	// StatusUnknown 未知
+	StatusUnknown Status = iota
+	// StatusServing 正常提供服务
+	StatusServing
+	// StatusNotServing 不提供服务
+	StatusNotServing
+)
+
+// String 状态描述
+func (s Status) String() string {
+	switch s {
+	case StatusServing:
+		return "SERVING"
+	case StatusNotServing:
+		return "NOT_SERVING"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Checker 依赖检查，例如 redis 客户端、注册中心、配置源
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc 函数形式的 Checker
+type CheckerFunc func(ctx context.Context) error
+
+// Check 执行检查
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// WatchFunc 就绪状态变更回调，回调按状态变更的顺序串行执行，回调中不能调用 Check、Resume 和 Shutdown
+type WatchFunc func(status Status)
+
+// Health 应用健康状态，存活状态随进程存在，
+// 就绪状态由应用生命周期和用户注册的检查共同决定
+type Health struct {
+	mu       sync.RWMutex
+	notifyMu sync.Mutex // 串行化状态计算和回调，保证监听者按变更顺序收到状态
+	serving  bool
+	shutdown bool
+	status   Status
+	checkers map[string]Checker
+	results  map[string]error
+	watchers []WatchFunc
+	interval time.Duration
+	timeout  time.Duration
+	once     sync.Once
+	done     chan struct{}
+}
+
+// New 创建健康状态
+func New(opts ...Option) *Health {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	h := &Health{
+		status:   StatusNotServing,
+		checkers: make(map[string]Checker),
+		results:  make(map[string]error),
+		interval: o.interval,
+		timeout:  o.timeout,
+		done:     make(chan struct{}),
+	}
+	for name, checker := range o.checkers {
+		h.checkers[name] = checker
+	}
+	return h
+}
+
+// Register 注册依赖检查
+func (h *Health) Register(name string, checker Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checkers[name] = checker
+}
+
+// Watch 监听就绪状态变更，注册时会立即回调一次当前状态
+func (h *Health) Watch(fn WatchFunc) {
+	h.notifyMu.Lock()
+	defer h.notifyMu.Unlock()
+	h.mu.Lock()
+	h.watchers = append(h.watchers, fn)
+	status := h.status
+	h.mu.Unlock()
+	fn(status)
+}
+
+// Resume 标记应用开始提供服务，并启动周期性的依赖检查
+func (h *Health) Resume() {
+	h.mu.Lock()
+	if h.shutdown {
+		h.mu.Unlock()
+		return
+	}
+	h.serving = true
+	h.mu.Unlock()
+	h.Check(context.Background())
+	h.once.Do(func() {
+		go h.loop()
+	})
+}
+
+// Shutdown 标记应用停止提供服务，之后就绪状态不会再恢复
+func (h *Health) Shutdown() {
+	h.mu.Lock()
+	if h.shutdown {
+		h.mu.Unlock()
+		return
+	}
+	h.shutdown = true
+	h.serving = false
+	close(h.done)
+	h.mu.Unlock()
+	h.update()
+}
+
+// Live 存活状态
+func (h *Health) Live() bool {
+	return true
+}
+
+// Ready 就绪状态
+func (h *Health) Ready() bool {
+	return h.Status() == StatusServing
+}
+
+// Status 当前就绪状态
+func (h *Health) Status() Status {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.status
+}
+
+// Results 最近一次依赖检查的结果
+func (h *Health) Results() map[string]error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	results := make(map[string]error, len(h.results))
+	for name, err := range h.results {
+		results[name] = err
+	}
+	return results
+}
+
+// Check 立即执行所有依赖检查并更新就绪状态
+func (h *Health) Check(ctx context.Context) map[string]error {
+	h.mu.RLock()
+	checkers := make(map[string]Checker, len(h.checkers))
+	for name, checker := range h.checkers {
+		checkers[name] = checker
+	}
+	h.mu.RUnlock()
+
+	results := make(map[string]error, len(checkers))
+	var (
+		wg     sync.WaitGroup
+		locker sync.Mutex
+	)
+	for name, checker := range checkers {
+		wg.Add(1)
+		go func(name string, checker Checker) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, h.timeout)
+			defer cancel()
+			err := checker.Check(checkCtx)
+			locker.Lock()
+			results[name] = err
+			locker.Unlock()
+		}(name, checker)
+	}
+	wg.Wait()
+
+	h.mu.Lock()
+	h.results = results
+	h.mu.Unlock()
+	h.update()
+	return results
+}
+
+// loop 周期性执行依赖检查
+func (h *Health) loop() {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.done:
+			return
+		case <-ticker.C:
+			h.Check(context.Background())
+		}
+	}
+}
+
+// update 重新计算就绪状态，状态变化时通知监听者
+func (h *Health) update() {
+	h.notifyMu.Lock()
+	defer h.notifyMu.Unlock()
+	h.mu.Lock()
+	status := StatusServing
+	if !h.serving {
+		status = StatusNotServing
+	}
+	for _, err := range h.results {
+		if err != nil {
+			status = StatusNotServing
+			break
+		}
+	}
+	if status == h.status {
+		h.mu.Unlock()
+		return
+	}
+	h.status = status
+	watchers := make([]WatchFunc, len(h.watchers))
+	copy(watchers, h.watchers)
+	h.mu.Unlock()
+	for _, fn := range watchers {
+		fn(status)
+	}
+}
+
+type healthKey struct{}
+
+// NewContext 创建附带健康状态的上下文
+func NewContext(ctx context.Context, h *Health) context.Context {
+	return context.WithValue(ctx, healthKey{}, h)
+}
+
+// FromContext 从上下文中获取健康状态
+func FromContext(ctx context.Context) (h *Health, ok bool) {
+	h, ok = ctx.Value(healthKey{}).(*Health)
+	return
+}
@@ -0,0 +1,94 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHealth_Ready(t *testing.T) {
+	var checkErr error
+	h := New(WithChecker("redis", CheckerFunc(func(ctx context.Context) error {
+		return checkErr
+	})))
+	var statuses []Status
+	h.Watch(func(status Status) {
+		statuses = append(statuses, status)
+	})
+	if h.Ready() {
+		t.Fatal("health should not be ready before resume")
+	}
+	h.Resume()
+	if !h.Ready() {
+		t.Fatal("health should be ready after resume")
+	}
+	checkErr = errors.New("connection refused")
+	h.Check(context.Background())
+	if h.Ready() {
+		t.Fatal("health should not be ready while checker fails")
+	}
+	checkErr = nil
+	h.Check(context.Background())
+	if !h.Ready() {
+		t.Fatal("health should be ready after checker recovers")
+	}
+	h.Shutdown()
+	h.Resume()
+	if h.Ready() {
+		t.Fatal("health should not be ready after shutdown")
+	}
+	want := []Status{StatusNotServing, StatusServing, StatusNotServing, StatusServing, StatusNotServing}
+	if len(statuses) != len(want) {
+		t.Fatalf("statuses = %v, want %v", statuses, want)
+	}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Fatalf("statuses = %v, want %v", statuses, want)
+		}
+	}
+}
+
+type failKey struct{}
+
+func TestHealth_WatchOrder(t *testing.T) {
+	// 每次检查的结果由调用方决定，并发的检查会交替改变就绪状态
+	h := New(WithChecker("redis", CheckerFunc(func(ctx context.Context) error {
+		if fail, _ := ctx.Value(failKey{}).(bool); fail {
+			return errors.New("connection refused")
+		}
+		return nil
+	})))
+	// 较慢的监听者放大并发通知的窗口
+	h.Watch(func(Status) {
+		time.Sleep(time.Millisecond)
+	})
+	var statuses []Status
+	h.Watch(func(status Status) {
+		statuses = append(statuses, status)
+	})
+	h.Resume()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i == 40 {
+				h.Shutdown()
+				return
+			}
+			h.Check(context.WithValue(context.Background(), failKey{}, i%2 == 0))
+		}(i)
+	}
+	wg.Wait()
+	// 回调按变更顺序串行执行，相邻状态不会重复，最后收到的状态与当前状态一致
+	for i := 1; i < len(statuses); i++ {
+		if statuses[i] == statuses[i-1] {
+			t.Fatalf("statuses = %v, want alternating statuses", statuses)
+		}
+	}
+	if last := statuses[len(statuses)-1]; last != StatusNotServing || h.Status() != StatusNotServing {
+		t.Fatalf("last status = %s, status = %s, want NOT_SERVING", last, h.Status())
+	}
+}
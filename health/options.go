@@ -0,0 +1,64 @@
+// Package health
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package health
+
+import "time"
+
+// Option 健康状态配置
+type Option func(o *options)
+
+type options struct {
+	interval time.Duration      // 依赖检查间隔
+	timeout  time.Duration      // 单个依赖检查超时时间
+	checkers map[string]Checker // 依赖检查
+}
+
+func defaultOptions() *options {
+	return &options{
+		interval: 5 * time.Second,
+		timeout:  time.Second,
+		checkers: make(map[string]Checker),
+	}
+}
+
+// Interval with check interval
+func Interval(interval time.Duration) Option {
+	return func(o *options) {
+		o.interval = interval
+	}
+}
+
+// Timeout with check timeout
+func Timeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithChecker with a named checker
+func WithChecker(name string, checker Checker) Option {
+	return func(o *options) {
+		o.checkers[name] = checker
+	}
+}
@@ -0,0 +1,71 @@
+// Package cpu
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package cpu
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	interval = 500 * time.Millisecond // 采样间隔
+	decay    = 0.95                   // 滑动平均衰减系数
+)
+
+var (
+	usage    int64
+	initOnce sync.Once
+)
+
+// Usage 进程 cpu 使用率的滑动平均值，单位千分比，范围 [0, 1000]
+func Usage() int64 {
+	initOnce.Do(func() {
+		go sample()
+	})
+	return atomic.LoadInt64(&usage)
+}
+
+// sample 周期性采样 cpu 使用率
+func sample() {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	prevCPU := processTime()
+	prevTime := time.Now()
+	for now := range ticker.C {
+		cpu := processTime()
+		elapsed := now.Sub(prevTime) * time.Duration(runtime.GOMAXPROCS(0))
+		if elapsed <= 0 {
+			continue
+		}
+		cur := int64(float64(cpu-prevCPU) / float64(elapsed) * 1000)
+		if cur > 1000 {
+			cur = 1000
+		}
+		prevCPU, prevTime = cpu, now
+		prev := atomic.LoadInt64(&usage)
+		atomic.StoreInt64(&usage, int64(float64(prev)*decay+float64(cur)*(1-decay)))
+	}
+}
@@ -0,0 +1,121 @@
+// Package window
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package window
+
+import (
+	"sync"
+	"time"
+)
+
+// Bucket 时间桶
+type Bucket struct {
+	Sum   float64 // 桶内数值之和
+	Count int64   // 桶内数值个数
+}
+
+// RollingCounter 滑动窗口计数器，窗口由 size 个时长为 bucketDuration 的时间桶组成
+type RollingCounter struct {
+	mu             sync.RWMutex
+	buckets        []Bucket
+	offset         int
+	bucketDuration time.Duration
+	lastAppendTime time.Time
+}
+
+// NewRollingCounter 创建滑动窗口计数器
+func NewRollingCounter(size int, bucketDuration time.Duration) *RollingCounter {
+	if size <= 0 {
+		size = 1
+	}
+	return &RollingCounter{
+		buckets:        make([]Bucket, size),
+		bucketDuration: bucketDuration,
+		lastAppendTime: time.Now(),
+	}
+}
+
+// Add 向当前时间桶添加数值
+func (r *RollingCounter) Add(val float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rotate(time.Now())
+	b := &r.buckets[r.offset]
+	b.Sum += val
+	b.Count++
+}
+
+// Reduce 依次遍历窗口内的时间桶，从最旧到最新
+func (r *RollingCounter) Reduce(fn func(b Bucket)) {
+	r.mu.Lock()
+	r.rotate(time.Now())
+	size := len(r.buckets)
+	buckets := make([]Bucket, 0, size)
+	for i := 1; i <= size; i++ {
+		buckets = append(buckets, r.buckets[(r.offset+i)%size])
+	}
+	r.mu.Unlock()
+	for _, b := range buckets {
+		fn(b)
+	}
+}
+
+// Sum 窗口内数值之和
+func (r *RollingCounter) Sum() (sum float64) {
+	r.Reduce(func(b Bucket) {
+		sum += b.Sum
+	})
+	return sum
+}
+
+// Count 窗口内数值个数
+func (r *RollingCounter) Count() (count int64) {
+	r.Reduce(func(b Bucket) {
+		count += b.Count
+	})
+	return count
+}
+
+// Size 时间桶个数
+func (r *RollingCounter) Size() int {
+	return len(r.buckets)
+}
+
+// BucketDuration 时间桶时长
+func (r *RollingCounter) BucketDuration() time.Duration {
+	return r.bucketDuration
+}
+
+// rotate 根据时间推移清理过期的时间桶
+func (r *RollingCounter) rotate(now time.Time) {
+	span := int(now.Sub(r.lastAppendTime) / r.bucketDuration)
+	if span <= 0 {
+		return
+	}
+	size := len(r.buckets)
+	for i := 1; i <= span && i <= size; i++ {
+		r.buckets[(r.offset+i)%size] = Bucket{}
+	}
+	r.offset = (r.offset + span) % size
+	r.lastAppendTime = r.lastAppendTime.Add(time.Duration(span) * r.bucketDuration)
+}
@@ -0,0 +1,63 @@
+// Package circuitbreaker
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package circuitbreaker
+
+import (
+	"github.com/go-fox/fox/errors"
+)
+
+// ErrNotAllowed 熔断器打开时返回的错误
+var ErrNotAllowed = errors.ServiceUnavailable("CIRCUIT_BREAKER", "request failed due to circuit breaker triggered")
+
+// State 熔断器状态
+type State int32
+
+const (
+	// StateClosed 关闭，请求正常通过
+	StateClosed State = iota
+	// StateOpen 打开，请求按概率被拒绝
+	StateOpen
+)
+
+// String 状态描述
+func (s State) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// Breaker 熔断器
+type Breaker interface {
+	// Allow 是否允许请求通过，不允许时返回 ErrNotAllowed
+	Allow() error
+	// MarkSuccess 标记请求成功
+	MarkSuccess()
+	// MarkFailed 标记请求失败
+	MarkFailed()
+	// State 当前状态
+	State() State
+}
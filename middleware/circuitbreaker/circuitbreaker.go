@@ -0,0 +1,153 @@
+// Package circuitbreaker
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package circuitbreaker
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/go-fox/fox/errors"
+	"github.com/go-fox/fox/middleware"
+	"github.com/go-fox/fox/transport"
+)
+
+// Group 按 operation 分组的熔断器
+type Group struct {
+	mu       sync.RWMutex
+	new      func() Breaker
+	breakers map[string]Breaker
+}
+
+// NewGroup 创建熔断器分组，fn 用于为每个 operation 创建熔断器
+func NewGroup(fn func() Breaker) *Group {
+	return &Group{
+		new:      fn,
+		breakers: make(map[string]Breaker),
+	}
+}
+
+// Get 获取 operation 对应的熔断器，不存在时创建
+func (g *Group) Get(operation string) Breaker {
+	g.mu.RLock()
+	b, ok := g.breakers[operation]
+	g.mu.RUnlock()
+	if ok {
+		return b
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if b, ok = g.breakers[operation]; ok {
+		return b
+	}
+	b = g.new()
+	g.breakers[operation] = b
+	return b
+}
+
+// States 所有熔断器的当前状态，可用于上报监控
+func (g *Group) States() map[string]State {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	states := make(map[string]State, len(g.breakers))
+	for operation, b := range g.breakers {
+		states[operation] = b.State()
+	}
+	return states
+}
+
+// Option 熔断中间件配置
+type Option func(o *options)
+
+type options struct {
+	group   *Group
+	trigger func(err error) bool
+}
+
+// WithGroup 使用指定的熔断器分组，便于在外部读取熔断器状态
+func WithGroup(g *Group) Option {
+	return func(o *options) {
+		o.group = g
+	}
+}
+
+// WithBreaker 使用 fn 为每个 operation 创建熔断器
+func WithBreaker(fn func() Breaker) Option {
+	return func(o *options) {
+		o.group = NewGroup(fn)
+	}
+}
+
+// WithTrigger 判断错误是否计为失败，默认只有服务端错误和网络错误计为失败
+func WithTrigger(fn func(err error) bool) Option {
+	return func(o *options) {
+		o.trigger = fn
+	}
+}
+
+// defaultTrigger 默认的失败判断
+func defaultTrigger(err error) bool {
+	switch errors.FromError(err).Code {
+	case 500, 503, 504,
+		int32(codes.Internal), int32(codes.Unavailable), int32(codes.DeadlineExceeded):
+		return true
+	default:
+		return false
+	}
+}
+
+// Client 客户端熔断中间件
+func Client(opts ...Option) middleware.Middleware {
+	o := &options{
+		group: NewGroup(func() Breaker {
+			return NewSRE()
+		}),
+		trigger: defaultTrigger,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			info, ok := transport.FromClientContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			breaker := o.group.Get(info.Operation())
+			if err := breaker.Allow(); err != nil {
+				// 被拒绝的请求同样计为失败，保证熔断期间持续拒绝
+				breaker.MarkFailed()
+				return nil, err
+			}
+			reply, err := handler(ctx, req)
+			if err != nil && o.trigger(err) {
+				breaker.MarkFailed()
+			} else {
+				breaker.MarkSuccess()
+			}
+			return reply, err
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package circuitbreaker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-fox/fox/errors"
+	"github.com/go-fox/fox/transport"
+)
+
+type testTransport struct {
+	transport.Transporter
+	operation string
+}
+
+func (tr *testTransport) Operation() string {
+	return tr.operation
+}
+
+func TestClient(t *testing.T) {
+	group := NewGroup(func() Breaker {
+		return NewSRE(WithRequest(10))
+	})
+	m := Client(WithGroup(group))
+	failed := m(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errors.ServiceUnavailable("UNAVAILABLE", "downstream unavailable")
+	})
+	ctx := transport.NewClientContext(context.Background(), &testTransport{operation: "/test.v1.Test/Fail"})
+	rejected := 0
+	for i := 0; i < 200; i++ {
+		if _, err := failed(ctx, nil); errors.Is(err, ErrNotAllowed) {
+			rejected++
+		}
+	}
+	if rejected == 0 {
+		t.Fatal("expected some requests to be rejected by circuit breaker")
+	}
+	if state := group.States()["/test.v1.Test/Fail"]; state != StateOpen {
+		t.Fatalf("state = %s, want %s", state, StateOpen)
+	}
+
+	ok := m(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	ctx = transport.NewClientContext(context.Background(), &testTransport{operation: "/test.v1.Test/Ok"})
+	for i := 0; i < 200; i++ {
+		if _, err := ok(ctx, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if state := group.States()["/test.v1.Test/Ok"]; state != StateClosed {
+		t.Fatalf("state = %s, want %s", state, StateClosed)
+	}
+}
+
+func TestSREInvalidOptions(t *testing.T) {
+	for _, opts := range [][]SREOption{
+		{WithBucket(0)},
+		{WithBucket(-1), WithWindow(0)},
+		{WithWindow(time.Nanosecond)},
+		{WithSuccess(0)},
+		{WithSuccess(-1)},
+		{WithSuccess(2)},
+	} {
+		b := NewSRE(opts...)
+		if k := b.(*sreBreaker).k; k != 1/defaultSuccess {
+			t.Fatalf("k = %v, want %v", k, 1/defaultSuccess)
+		}
+		for i := 0; i < 10; i++ {
+			b.MarkSuccess()
+		}
+		if err := b.Allow(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Package circuitbreaker
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package circuitbreaker
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-fox/fox/internal/window"
+)
+
+// SREOption sre 熔断器配置
+type SREOption func(o *sreOptions)
+
+type sreOptions struct {
+	success float64       // 成功率阈值
+	request int64         // 最小请求数
+	window  time.Duration // 统计窗口
+	bucket  int           // 统计窗口的时间桶个数
+}
+
+// WithSuccess 成功率阈值，默认 0.6，值越小越容易触发熔断
+func WithSuccess(success float64) SREOption {
+	return func(o *sreOptions) {
+		o.success = success
+	}
+}
+
+// WithRequest 窗口内请求数低于该值时不会触发熔断，默认 100
+func WithRequest(request int64) SREOption {
+	return func(o *sreOptions) {
+		o.request = request
+	}
+}
+
+// WithWindow 统计窗口，默认 3s
+func WithWindow(d time.Duration) SREOption {
+	return func(o *sreOptions) {
+		o.window = d
+	}
+}
+
+// WithBucket 统计窗口的时间桶个数，默认 10
+func WithBucket(bucket int) SREOption {
+	return func(o *sreOptions) {
+		o.bucket = bucket
+	}
+}
+
+// sreBreaker Google SRE 自适应熔断器
+//
+// 客户端按 max(0, (requests - k*accepts) / (requests + 1)) 的概率主动拒绝请求，
+// 其中 k = 1 / success
+type sreBreaker struct {
+	stat     *window.RollingCounter
+	k        float64
+	request  int64
+	state    int32
+	randLock sync.Mutex
+	r        *rand.Rand
+}
+
+const (
+	defaultSuccess = 0.6
+	defaultWindow  = 3 * time.Second
+	defaultBucket  = 10
+)
+
+// NewSRE 创建 sre 自适应熔断器，success、window 或 bucket 无效时使用默认值
+func NewSRE(opts ...SREOption) Breaker {
+	o := &sreOptions{
+		success: defaultSuccess,
+		request: 100,
+		window:  defaultWindow,
+		bucket:  defaultBucket,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	// success 需在 (0, 1] 之间，否则 k 为无穷大或小于 1
+	if !(o.success > 0 && o.success <= 1) {
+		o.success = defaultSuccess
+	}
+	if o.window <= 0 {
+		o.window = defaultWindow
+	}
+	if o.bucket <= 0 || o.window < time.Duration(o.bucket) {
+		o.bucket = defaultBucket
+	}
+	if o.window < time.Duration(o.bucket) {
+		o.window = defaultWindow
+	}
+	return &sreBreaker{
+		stat:    window.NewRollingCounter(o.bucket, o.window/time.Duration(o.bucket)),
+		k:       1 / o.success,
+		request: o.request,
+		state:   int32(StateClosed),
+		r:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Allow 是否允许请求通过
+func (b *sreBreaker) Allow() error {
+	accepts, total := b.summary()
+	requests := b.k * accepts
+	if total < b.request || float64(total) < requests {
+		atomic.CompareAndSwapInt32(&b.state, int32(StateOpen), int32(StateClosed))
+		return nil
+	}
+	atomic.CompareAndSwapInt32(&b.state, int32(StateClosed), int32(StateOpen))
+	dr := math.Max(0, (float64(total)-requests)/float64(total+1))
+	if b.trueOnProba(dr) {
+		return ErrNotAllowed
+	}
+	return nil
+}
+
+// MarkSuccess 标记成功
+func (b *sreBreaker) MarkSuccess() {
+	b.stat.Add(1)
+}
+
+// MarkFailed 标记失败
+func (b *sreBreaker) MarkFailed() {
+	b.stat.Add(0)
+}
+
+// State 当前状态
+func (b *sreBreaker) State() State {
+	return State(atomic.LoadInt32(&b.state))
+}
+
+// summary 窗口内成功数和总请求数
+func (b *sreBreaker) summary() (accepts float64, total int64) {
+	b.stat.Reduce(func(bucket window.Bucket) {
+		accepts += bucket.Sum
+		total += bucket.Count
+	})
+	return
+}
+
+// trueOnProba 以 proba 的概率返回 true
+func (b *sreBreaker) trueOnProba(proba float64) bool {
+	b.randLock.Lock()
+	defer b.randLock.Unlock()
+	return b.r.Float64() < proba
+}
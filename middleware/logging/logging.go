@@ -0,0 +1,196 @@
+// Package logging
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/go-fox/fox/errors"
+	"github.com/go-fox/fox/middleware"
+	"github.com/go-fox/fox/middleware/tracing"
+	"github.com/go-fox/fox/selector"
+	"github.com/go-fox/fox/transport"
+)
+
+// Redacter 请求或响应实现该接口时，记录日志使用 Redact 的结果，用于脱敏
+type Redacter interface {
+	Redact() string
+}
+
+// Option 日志中间件配置
+type Option func(o *options)
+
+type options struct {
+	logger        *slog.Logger
+	slowThreshold time.Duration
+	sampleRate    float64
+	body          bool
+}
+
+func defaultOptions() *options {
+	return &options{
+		logger:     slog.With(slog.String("mod", "middleware.logging")),
+		sampleRate: 1,
+	}
+}
+
+// WithLogger 设置日志记录器
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithSlowThreshold 慢请求阈值，耗时超过阈值的请求使用 Warn 级别记录，0 表示不开启
+func WithSlowThreshold(threshold time.Duration) Option {
+	return func(o *options) {
+		o.slowThreshold = threshold
+	}
+}
+
+// WithSampleRate 正常请求的采样率，取值 [0, 1]，默认 1；出错和慢请求总是会被记录
+func WithSampleRate(rate float64) Option {
+	return func(o *options) {
+		o.sampleRate = rate
+	}
+}
+
+// WithBody 是否记录请求和响应内容，实现了 Redacter 的内容会先脱敏
+func WithBody(body bool) Option {
+	return func(o *options) {
+		o.body = body
+	}
+}
+
+// Server 服务端访问日志中间件
+func Server(opts ...Option) middleware.Middleware {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			start := time.Now()
+			reply, err := handler(ctx, req)
+			remoteAddr := ""
+			if addr := tr.RemoteAddr(); addr != nil {
+				remoteAddr = addr.String()
+			}
+			o.log(ctx, "server", tr, remoteAddr, start, req, reply, err)
+			return reply, err
+		}
+	}
+}
+
+// Client 客户端访问日志中间件，remote_addr 为本次选中的节点地址
+func Client(opts ...Option) middleware.Middleware {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromClientContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			start := time.Now()
+			reply, err := handler(ctx, req)
+			remoteAddr := tr.Endpoint()
+			if p, ok := selector.FromPeerContext(ctx); ok && p.Node != nil {
+				remoteAddr = p.Node.Address()
+			}
+			o.log(ctx, "client", tr, remoteAddr, start, req, reply, err)
+			return reply, err
+		}
+	}
+}
+
+// log 记录一次请求
+func (o *options) log(ctx context.Context, side string, tr transport.Transporter, remoteAddr string, start time.Time, req, reply interface{}, err error) {
+	latency := time.Since(start)
+	slow := o.slowThreshold > 0 && latency >= o.slowThreshold
+	code, reason := 200, ""
+	level := slog.LevelInfo
+	if se := errors.FromError(err); se != nil {
+		code, reason = int(se.Code), se.Reason
+		level = slog.LevelWarn
+		if code >= 500 {
+			level = slog.LevelError
+		}
+	} else if slow {
+		level = slog.LevelWarn
+	} else if o.sampleRate < 1 && rand.Float64() >= o.sampleRate {
+		return
+	}
+	if !o.logger.Enabled(ctx, level) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("kind", tr.Kind().String()),
+		slog.String("operation", tr.Operation()),
+		slog.String("remote_addr", remoteAddr),
+		slog.Duration("latency", latency),
+		slog.Int("code", code),
+		slog.String("reason", reason),
+	}
+	if slow {
+		attrs = append(attrs, slog.Bool("slow", true))
+	}
+	if traceID := tracing.TraceID(ctx); traceID != "" {
+		attrs = append(attrs, slog.String("trace_id", traceID), slog.String("span_id", tracing.SpanID(ctx)))
+	}
+	if o.body {
+		attrs = append(attrs, slog.String("request", extractArgs(req)))
+		if err == nil {
+			attrs = append(attrs, slog.String("reply", extractArgs(reply)))
+		}
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	o.logger.LogAttrs(ctx, level, side, attrs...)
+}
+
+// extractArgs 格式化请求或响应内容
+func extractArgs(v interface{}) string {
+	switch vv := v.(type) {
+	case nil:
+		return ""
+	case Redacter:
+		return vv.Redact()
+	case fmt.Stringer:
+		return vv.String()
+	default:
+		return fmt.Sprintf("%+v", v)
+	}
+}
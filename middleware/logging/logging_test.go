@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-fox/fox/errors"
+	"github.com/go-fox/fox/transport"
+)
+
+type testTransport struct {
+	transport.Transporter
+	operation string
+}
+
+func (tr *testTransport) Kind() transport.Kind {
+	return "test"
+}
+
+func (tr *testTransport) Operation() string {
+	return tr.operation
+}
+
+func (tr *testTransport) RemoteAddr() net.Addr {
+	return nil
+}
+
+type secret string
+
+func (s secret) Redact() string {
+	return "***"
+}
+
+func TestServer(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+	m := Server(WithLogger(logger), WithBody(true), WithSampleRate(0), WithSlowThreshold(10*time.Millisecond))
+	h := m(func(ctx context.Context, req interface{}) (interface{}, error) {
+		switch req {
+		case "fail":
+			return nil, errors.ServiceUnavailable("UNAVAILABLE", "unavailable")
+		case "slow":
+			time.Sleep(20 * time.Millisecond)
+		}
+		return secret("token"), nil
+	})
+	ctx := transport.NewServerContext(context.Background(), &testTransport{operation: "/test.v1.Test/Foo"})
+	for _, req := range []string{"ok", "fail", "slow"} {
+		_, _ = h(ctx, req)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2 (sampled out success should be dropped):\n%s", len(lines), buf.String())
+	}
+	var failed, slow map[string]interface{}
+	_ = json.Unmarshal([]byte(lines[0]), &failed)
+	_ = json.Unmarshal([]byte(lines[1]), &slow)
+	if failed["level"] != "ERROR" || failed["code"] != float64(503) || failed["reason"] != "UNAVAILABLE" {
+		t.Fatalf("unexpected error log: %v", failed)
+	}
+	if slow["level"] != "WARN" || slow["slow"] != true || slow["reply"] != "***" {
+		t.Fatalf("unexpected slow log: %v", slow)
+	}
+	if slow["operation"] != "/test.v1.Test/Foo" || slow["kind"] != "test" {
+		t.Fatalf("unexpected transport fields: %v", slow)
+	}
+}
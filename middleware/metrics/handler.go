@@ -0,0 +1,75 @@
+// Package metrics
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+
+	"github.com/go-fox/fox/transport/http"
+)
+
+// Path 默认的监控指标路径
+const Path = "/metrics"
+
+// SessionCounter session 数量，websocket.Server 实现了该接口
+type SessionCounter interface {
+	SessionCount() int64
+}
+
+// Handler 输出监控指标的 http handler
+func Handler(opts ...Option) http.Handler {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	h := fasthttpadaptor.NewFastHTTPHandler(promhttp.HandlerFor(o.gatherer, promhttp.HandlerOpts{}))
+	return func(ctx *http.Context) error {
+		h(ctx.FastCtx())
+		return nil
+	}
+}
+
+// Mount 在 router 上挂载 /metrics
+func Mount(r http.Router, opts ...Option) {
+	r.Get(Path, Handler(opts...))
+}
+
+// RegisterSessions 注册 websocket session 数量指标
+func RegisterSessions(name string, counter SessionCounter, opts ...Option) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	register(o.registerer, prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   o.namespace,
+		Subsystem:   "websocket",
+		Name:        "sessions",
+		Help:        "The number of connected websocket sessions",
+		ConstLabels: prometheus.Labels{"server": name},
+	}, func() float64 {
+		return float64(counter.SessionCount())
+	}))
+}
@@ -0,0 +1,127 @@
+// Package metrics
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/go-fox/fox/errors"
+	"github.com/go-fox/fox/middleware"
+	"github.com/go-fox/fox/selector"
+	"github.com/go-fox/fox/transport"
+)
+
+// requestMetrics 请求指标
+type requestMetrics struct {
+	requests *prometheus.CounterVec
+	seconds  *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+}
+
+// newRequestMetrics 创建请求指标，side 为 server 或 client
+func newRequestMetrics(o *options, side string) *requestMetrics {
+	return &requestMetrics{
+		requests: register(o.registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace,
+			Subsystem: side,
+			Name:      "requests_total",
+			Help:      "The total number of processed requests",
+		}, []string{"kind", "operation", "code", "reason"})),
+		seconds: register(o.registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: o.namespace,
+			Subsystem: side,
+			Name:      "requests_duration_seconds",
+			Help:      "Requests duration(sec).",
+			Buckets:   o.buckets,
+		}, []string{"kind", "operation"})),
+		inFlight: register(o.registerer, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: o.namespace,
+			Subsystem: side,
+			Name:      "requests_in_flight",
+			Help:      "The number of requests being processed",
+		}, []string{"kind", "operation"})),
+	}
+}
+
+// observe 记录一次请求
+func (m *requestMetrics) observe(ctx context.Context, tr transport.Transporter, req interface{}, handler middleware.Handler) (interface{}, error) {
+	kind, operation := tr.Kind().String(), tr.Operation()
+	inFlight := m.inFlight.WithLabelValues(kind, operation)
+	inFlight.Inc()
+	defer inFlight.Dec()
+	start := time.Now()
+	reply, err := handler(ctx, req)
+	code, reason := 200, ""
+	if se := errors.FromError(err); se != nil {
+		code, reason = int(se.Code), se.Reason
+	}
+	m.requests.WithLabelValues(kind, operation, strconv.Itoa(code), reason).Inc()
+	m.seconds.WithLabelValues(kind, operation).Observe(time.Since(start).Seconds())
+	return reply, err
+}
+
+// Server 服务端监控中间件
+func Server(opts ...Option) middleware.Middleware {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	m := newRequestMetrics(o, "server")
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			return m.observe(ctx, tr, req, handler)
+		}
+	}
+}
+
+// Client 客户端监控中间件，同时记录每个节点被选中的次数
+func Client(opts ...Option) middleware.Middleware {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	m := newRequestMetrics(o, "client")
+	picks := newSelectorMetrics(o).picks
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromClientContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			reply, err := m.observe(ctx, tr, req, handler)
+			if p, ok := selector.FromPeerContext(ctx); ok && p.Node != nil {
+				picks.WithLabelValues(p.Node.ServiceName(), p.Node.Address()).Inc()
+			}
+			return reply, err
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/go-fox/fox/errors"
+	"github.com/go-fox/fox/transport"
+)
+
+type testTransport struct {
+	transport.Transporter
+	operation string
+}
+
+func (tr *testTransport) Kind() transport.Kind {
+	return "test"
+}
+
+func (tr *testTransport) Operation() string {
+	return tr.operation
+}
+
+func TestServer(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := Server(WithRegisterer(reg))
+	h := m(func(ctx context.Context, req interface{}) (interface{}, error) {
+		if req == "fail" {
+			return nil, errors.ServiceUnavailable("UNAVAILABLE", "unavailable")
+		}
+		return "ok", nil
+	})
+	ctx := transport.NewServerContext(context.Background(), &testTransport{operation: "/test.v1.Test/Foo"})
+	_, _ = h(ctx, "ok")
+	_, _ = h(ctx, "ok")
+	_, _ = h(ctx, "fail")
+
+	// 同一注册器重复创建中间件不应 panic
+	_ = Server(WithRegisterer(reg))
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]float64{}
+	for _, f := range families {
+		if f.GetName() != "fox_server_requests_total" {
+			continue
+		}
+		for _, metric := range f.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range metric.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			got[labels["code"]+"/"+labels["reason"]] = metric.GetCounter().GetValue()
+		}
+	}
+	if got["200/"] != 2 {
+		t.Fatalf("success count = %v, want 2", got["200/"])
+	}
+	if got["503/UNAVAILABLE"] != 1 {
+		t.Fatalf("failure count = %v, want 1", got["503/UNAVAILABLE"])
+	}
+}
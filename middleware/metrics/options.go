@@ -0,0 +1,91 @@
+// Package metrics
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package metrics
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Option 监控配置
+type Option func(o *options)
+
+type options struct {
+	namespace  string
+	registerer prometheus.Registerer
+	gatherer   prometheus.Gatherer
+	buckets    []float64
+}
+
+func defaultOptions() *options {
+	return &options{
+		namespace:  "fox",
+		registerer: prometheus.DefaultRegisterer,
+		gatherer:   prometheus.DefaultGatherer,
+		buckets:    []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}
+}
+
+// WithNamespace 指标命名空间，默认 fox
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// WithRegisterer 指标注册器，默认 prometheus.DefaultRegisterer
+func WithRegisterer(registerer prometheus.Registerer) Option {
+	return func(o *options) {
+		o.registerer = registerer
+	}
+}
+
+// WithGatherer 指标收集器，默认 prometheus.DefaultGatherer
+func WithGatherer(gatherer prometheus.Gatherer) Option {
+	return func(o *options) {
+		o.gatherer = gatherer
+	}
+}
+
+// WithBuckets 请求耗时直方图的分桶，单位秒
+func WithBuckets(buckets []float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// register 注册指标，已经注册过时返回已注册的指标
+func register[T prometheus.Collector](registerer prometheus.Registerer, c T) T {
+	if err := registerer.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return c
+}
@@ -0,0 +1,111 @@
+// Package metrics
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/go-fox/fox/selector"
+	"github.com/go-fox/fox/selector/base"
+)
+
+// selectorMetrics 负载均衡指标
+type selectorMetrics struct {
+	picks     *prometheus.CounterVec
+	weight    *prometheus.GaugeVec
+	ejections *prometheus.CounterVec
+	ejected   *prometheus.GaugeVec
+}
+
+// newSelectorMetrics 创建负载均衡指标
+func newSelectorMetrics(o *options) *selectorMetrics {
+	return &selectorMetrics{
+		picks: register(o.registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace,
+			Subsystem: "selector",
+			Name:      "picks_total",
+			Help:      "The total number of times each node was picked",
+		}, []string{"service", "node"})),
+		weight: register(o.registerer, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: o.namespace,
+			Subsystem: "selector",
+			Name:      "node_weight",
+			Help:      "The runtime calculated weight of each node",
+		}, []string{"service", "node"})),
+		ejections: register(o.registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace,
+			Subsystem: "selector",
+			Name:      "ejections_total",
+			Help:      "The total number of times each node was ejected by outlier detection",
+		}, []string{"service", "node"})),
+		ejected: register(o.registerer, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: o.namespace,
+			Subsystem: "selector",
+			Name:      "node_ejected",
+			Help:      "Whether the node is currently ejected by outlier detection",
+		}, []string{"service", "node"})),
+	}
+}
+
+// NodeFilter 记录候选节点实时权重的节点过滤器，不会过滤任何节点，
+// 需要放在其他过滤器之后，例如 http.WithNodeFilters(metrics.NodeFilter())
+func NodeFilter(opts ...Option) selector.NodeFilter {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	weight := newSelectorMetrics(o).weight
+	return func(ctx context.Context, nodes []selector.Node) []selector.Node {
+		for _, n := range nodes {
+			if wn, ok := n.(selector.WeightedNode); ok {
+				weight.WithLabelValues(wn.ServiceName(), wn.Address()).Set(wn.Weight())
+			}
+		}
+		return nodes
+	}
+}
+
+// OutlierHook 记录节点驱逐次数和驱逐状态的回调，
+// 例如 base.SetOutlierConfig(&base.OutlierConfig{..., Hooks: []base.OutlierHook{metrics.OutlierHook()}})
+func OutlierHook(opts ...Option) base.OutlierHook {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	return newSelectorMetrics(o)
+}
+
+// OnEject 节点被驱逐
+func (m *selectorMetrics) OnEject(node selector.Node, _ time.Duration) {
+	m.ejections.WithLabelValues(node.ServiceName(), node.Address()).Inc()
+	m.ejected.WithLabelValues(node.ServiceName(), node.Address()).Set(1)
+}
+
+// OnReadmit 节点恢复
+func (m *selectorMetrics) OnReadmit(node selector.Node) {
+	m.ejected.WithLabelValues(node.ServiceName(), node.Address()).Set(0)
+}
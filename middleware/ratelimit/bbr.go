@@ -0,0 +1,185 @@
+// Package ratelimit
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package ratelimit
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-fox/fox/internal/cpu"
+	"github.com/go-fox/fox/internal/window"
+)
+
+// BBROption bbr 限流器配置
+type BBROption func(o *bbrOptions)
+
+type bbrOptions struct {
+	window       time.Duration // 统计窗口
+	bucket       int           // 统计窗口的时间桶个数
+	cpuThreshold int64         // cpu 使用率阈值，千分比
+	cpuUsage     func() int64  // cpu 使用率，千分比
+}
+
+// WithWindow 统计窗口，默认 10s
+func WithWindow(d time.Duration) BBROption {
+	return func(o *bbrOptions) {
+		o.window = d
+	}
+}
+
+// WithBucket 统计窗口的时间桶个数，默认 100
+func WithBucket(bucket int) BBROption {
+	return func(o *bbrOptions) {
+		o.bucket = bucket
+	}
+}
+
+// WithCPUThreshold cpu 使用率阈值，千分比，默认 800
+func WithCPUThreshold(threshold int64) BBROption {
+	return func(o *bbrOptions) {
+		o.cpuThreshold = threshold
+	}
+}
+
+// WithCPUUsage 自定义 cpu 使用率获取方式，返回千分比
+func WithCPUUsage(fn func() int64) BBROption {
+	return func(o *bbrOptions) {
+		o.cpuUsage = fn
+	}
+}
+
+// bbr 基于 BBR 思想的自适应限流器
+//
+// cpu 使用率超过阈值时，按窗口内的最大通过量和最小响应时间估算系统容量
+// maxInFlight = maxPass * bucketPerSecond * minRT，并发超过容量的请求会被拒绝
+type bbr struct {
+	opts         *bbrOptions
+	passStat     *window.RollingCounter
+	rtStat       *window.RollingCounter
+	inFlight     int64
+	bucketPerSec float64
+	prevDropTime atomic.Value
+}
+
+const (
+	defaultWindow = 10 * time.Second
+	defaultBucket = 100
+)
+
+// NewBBR 创建 bbr 自适应限流器，window 或 bucket 无效时使用默认值
+func NewBBR(opts ...BBROption) Limiter {
+	o := &bbrOptions{
+		window:       defaultWindow,
+		bucket:       defaultBucket,
+		cpuThreshold: 800,
+		cpuUsage:     cpu.Usage,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.window <= 0 {
+		o.window = defaultWindow
+	}
+	if o.bucket <= 0 || o.window < time.Duration(o.bucket) {
+		o.bucket = defaultBucket
+	}
+	if o.window < time.Duration(o.bucket) {
+		o.window = defaultWindow
+	}
+	bucketDuration := o.window / time.Duration(o.bucket)
+	l := &bbr{
+		opts:         o,
+		passStat:     window.NewRollingCounter(o.bucket, bucketDuration),
+		rtStat:       window.NewRollingCounter(o.bucket, bucketDuration),
+		bucketPerSec: float64(time.Second) / float64(bucketDuration),
+	}
+	l.prevDropTime.Store(time.Time{})
+	return l
+}
+
+// Allow 是否允许请求通过
+func (l *bbr) Allow() (DoneFunc, error) {
+	if l.shouldDrop() {
+		return nil, ErrLimitExceed
+	}
+	atomic.AddInt64(&l.inFlight, 1)
+	start := time.Now()
+	return func(DoneInfo) {
+		if rt := time.Since(start).Milliseconds(); rt > 0 {
+			l.rtStat.Add(float64(rt))
+		} else {
+			l.rtStat.Add(0)
+		}
+		atomic.AddInt64(&l.inFlight, -1)
+		l.passStat.Add(1)
+	}, nil
+}
+
+// maxPass 窗口内单个时间桶的最大通过量
+func (l *bbr) maxPass() float64 {
+	var maxPass float64
+	l.passStat.Reduce(func(b window.Bucket) {
+		maxPass = math.Max(maxPass, b.Sum)
+	})
+	return math.Max(maxPass, 1)
+}
+
+// minRT 窗口内单个时间桶的最小平均响应时间，单位毫秒
+func (l *bbr) minRT() float64 {
+	minRT := math.MaxFloat64
+	l.rtStat.Reduce(func(b window.Bucket) {
+		if b.Count > 0 {
+			minRT = math.Min(minRT, b.Sum/float64(b.Count))
+		}
+	})
+	if minRT == math.MaxFloat64 || minRT < 1 {
+		return 1
+	}
+	return minRT
+}
+
+// maxInFlight 估算的系统最大并发
+func (l *bbr) maxInFlight() int64 {
+	return int64(math.Ceil(l.maxPass() * l.minRT() * l.bucketPerSec / 1000))
+}
+
+// shouldDrop 是否需要拒绝请求
+func (l *bbr) shouldDrop() bool {
+	now := time.Now()
+	inFlight := atomic.LoadInt64(&l.inFlight)
+	if l.opts.cpuUsage() < l.opts.cpuThreshold {
+		// cpu 恢复后的 1s 冷却期内仍按容量限流，避免抖动
+		prevDropTime, _ := l.prevDropTime.Load().(time.Time)
+		if prevDropTime.IsZero() || now.Sub(prevDropTime) > time.Second {
+			return false
+		}
+		return inFlight > 1 && inFlight > l.maxInFlight()
+	}
+	drop := inFlight > 1 && inFlight > l.maxInFlight()
+	if drop {
+		l.prevDropTime.Store(now)
+	}
+	return drop
+}
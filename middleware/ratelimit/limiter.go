@@ -0,0 +1,45 @@
+// Package ratelimit
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package ratelimit
+
+import (
+	"github.com/go-fox/fox/errors"
+)
+
+// ErrLimitExceed 请求被限流时返回的错误
+var ErrLimitExceed = errors.TooManyRequests("RATELIMIT", "service unavailable due to rate limit exceeded")
+
+// DoneInfo 请求完成信息
+type DoneInfo struct {
+	Err error
+}
+
+// DoneFunc 请求完成回调
+type DoneFunc func(DoneInfo)
+
+// Limiter 限流器
+type Limiter interface {
+	// Allow 是否允许请求通过，允许时返回请求完成回调，不允许时返回 ErrLimitExceed
+	Allow() (DoneFunc, error)
+}
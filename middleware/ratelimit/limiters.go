@@ -0,0 +1,104 @@
+// Package ratelimit
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package ratelimit
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// limiterCache 按 key 保存限流器，超过容量时淘汰最久未使用的 key，长时间未使用的 key 也会被淘汰，
+// 避免按客户端 ip 等维度限流时 key 无限增长
+type limiterCache struct {
+	mu         sync.Mutex
+	size       int
+	ttl        time.Duration
+	newLimiter func() Limiter
+	ll         *list.List // 最近使用的在前
+	items      map[string]*list.Element
+	now        func() time.Time
+}
+
+type limiterEntry struct {
+	key      string
+	limiter  Limiter
+	lastUsed time.Time
+}
+
+func newLimiterCache(size int, ttl time.Duration, newLimiter func() Limiter) *limiterCache {
+	return &limiterCache{
+		size:       size,
+		ttl:        ttl,
+		newLimiter: newLimiter,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// get 获取 key 的限流器，不存在时创建
+func (c *limiterCache) get(key string) Limiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if e, ok := c.items[key]; ok {
+		entry := e.Value.(*limiterEntry)
+		if c.ttl <= 0 || now.Sub(entry.lastUsed) < c.ttl {
+			entry.lastUsed = now
+			c.ll.MoveToFront(e)
+			return entry.limiter
+		}
+		c.remove(e)
+	}
+	c.evict(now)
+	entry := &limiterEntry{key: key, limiter: c.newLimiter(), lastUsed: now}
+	c.items[key] = c.ll.PushFront(entry)
+	return entry.limiter
+}
+
+// evict 从最久未使用的 key 开始淘汰过期的 key，并为新 key 腾出空间，调用方需持有锁
+func (c *limiterCache) evict(now time.Time) {
+	for e := c.ll.Back(); e != nil; e = c.ll.Back() {
+		entry := e.Value.(*limiterEntry)
+		expired := c.ttl > 0 && now.Sub(entry.lastUsed) >= c.ttl
+		if !expired && (c.size <= 0 || c.ll.Len() < c.size) {
+			return
+		}
+		c.remove(e)
+	}
+}
+
+// remove 删除 key，调用方需持有锁
+func (c *limiterCache) remove(e *list.Element) {
+	c.ll.Remove(e)
+	delete(c.items, e.Value.(*limiterEntry).key)
+}
+
+// len 缓存的 key 数量
+func (c *limiterCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
@@ -0,0 +1,155 @@
+// Package ratelimit
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/go-fox/fox/middleware"
+	"github.com/go-fox/fox/transport"
+)
+
+// KeyFunc 从请求中提取限流的维度，相同 key 的请求共享一个限流器
+type KeyFunc func(ctx context.Context, tr transport.Transporter) string
+
+// ByOperation 按 operation 限流
+func ByOperation() KeyFunc {
+	return func(ctx context.Context, tr transport.Transporter) string {
+		return tr.Operation()
+	}
+}
+
+// ByRemoteIP 按客户端 ip 限流
+func ByRemoteIP() KeyFunc {
+	return func(ctx context.Context, tr transport.Transporter) string {
+		addr := tr.RemoteAddr()
+		if addr == nil {
+			return ""
+		}
+		if host, _, err := net.SplitHostPort(addr.String()); err == nil {
+			return host
+		}
+		return addr.String()
+	}
+}
+
+// ByHeader 按请求头的值限流
+func ByHeader(key string) KeyFunc {
+	return func(ctx context.Context, tr transport.Transporter) string {
+		return tr.RequestHeader().Get(key)
+	}
+}
+
+// Option 限流中间件配置
+type Option func(o *options)
+
+const (
+	defaultMaxKeys = 10000
+	defaultKeyTTL  = 10 * time.Minute
+)
+
+type options struct {
+	newLimiter func() Limiter
+	key        KeyFunc
+	maxKeys    int
+	keyTTL     time.Duration
+}
+
+// WithLimiter 使用同一个限流器限制所有请求
+func WithLimiter(l Limiter) Option {
+	return func(o *options) {
+		o.newLimiter = func() Limiter {
+			return l
+		}
+	}
+}
+
+// WithLimiterFunc 使用 fn 为每个 key 创建限流器，需要配合 WithKey 使用
+func WithLimiterFunc(fn func() Limiter) Option {
+	return func(o *options) {
+		o.newLimiter = fn
+	}
+}
+
+// WithKey 设置限流维度，默认所有请求共享一个限流器
+func WithKey(fn KeyFunc) Option {
+	return func(o *options) {
+		o.key = fn
+	}
+}
+
+// WithMaxKeys 设置按 key 限流时最多保存的限流器数量，超过后淘汰最久未使用的 key，默认 10000，小于等于 0 时不限制
+func WithMaxKeys(n int) Option {
+	return func(o *options) {
+		o.maxKeys = n
+	}
+}
+
+// WithKeyTTL 设置按 key 限流时限流器的空闲时间，超过后淘汰，默认 10 分钟，小于等于 0 时不淘汰
+func WithKeyTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.keyTTL = ttl
+	}
+}
+
+// Server 服务端限流中间件
+func Server(opts ...Option) middleware.Middleware {
+	o := &options{
+		newLimiter: func() Limiter {
+			return NewBBR()
+		},
+		maxKeys: defaultMaxKeys,
+		keyTTL:  defaultKeyTTL,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	var (
+		limiter  = o.newLimiter()
+		limiters = newLimiterCache(o.maxKeys, o.keyTTL, o.newLimiter)
+	)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			l := limiter
+			if o.key != nil {
+				if tr, ok := transport.FromServerContext(ctx); ok {
+					l = limiters.get(o.key(ctx, tr))
+				}
+			}
+			done, err := l.Allow()
+			if err != nil {
+				return nil, err
+			}
+			// handler panic 时同样归还并发计数
+			var reply interface{}
+			defer func() {
+				done(DoneInfo{Err: err})
+			}()
+			reply, err = handler(ctx, req)
+			return reply, err
+		}
+	}
+}
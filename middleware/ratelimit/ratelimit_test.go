@@ -0,0 +1,151 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-fox/fox/errors"
+	"github.com/go-fox/fox/transport"
+)
+
+type testTransport struct {
+	transport.Transporter
+	operation string
+}
+
+func (tr *testTransport) Operation() string {
+	return tr.operation
+}
+
+func TestServer(t *testing.T) {
+	m := Server(
+		WithKey(ByOperation()),
+		WithLimiterFunc(func() Limiter {
+			return NewTokenBucket(0, 2)
+		}),
+	)
+	h := m(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	call := func(operation string) error {
+		ctx := transport.NewServerContext(context.Background(), &testTransport{operation: operation})
+		_, err := h(ctx, nil)
+		return err
+	}
+	for i := 0; i < 2; i++ {
+		if err := call("/test.v1.Test/Foo"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := call("/test.v1.Test/Foo"); !errors.IsTooManyRequests(err) {
+		t.Fatalf("err = %v, want too many requests", err)
+	}
+	if err := call("/test.v1.Test/Bar"); err != nil {
+		t.Fatalf("operations should be limited separately, got %v", err)
+	}
+}
+
+func TestBBR(t *testing.T) {
+	l := NewBBR(WithCPUUsage(func() int64 { return 900 }))
+	for i := 0; i < 10; i++ {
+		done, err := l.Allow()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		done(DoneInfo{})
+	}
+	// 高 cpu 下并发超过估算容量的请求会被拒绝
+	var dones []DoneFunc
+	var rejected bool
+	for i := 0; i < 100; i++ {
+		done, err := l.Allow()
+		if err != nil {
+			rejected = true
+			break
+		}
+		dones = append(dones, done)
+	}
+	for _, done := range dones {
+		done(DoneInfo{})
+	}
+	if !rejected {
+		t.Fatal("expected bbr to reject requests under high cpu usage")
+	}
+}
+
+// countLimiter 记录未归还的请求数
+type countLimiter struct {
+	inFlight int
+}
+
+func (l *countLimiter) Allow() (DoneFunc, error) {
+	l.inFlight++
+	return func(DoneInfo) { l.inFlight-- }, nil
+}
+
+func TestServerPanic(t *testing.T) {
+	l := &countLimiter{}
+	h := Server(WithLimiterFunc(func() Limiter { return l }))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	})
+	func() {
+		defer func() { _ = recover() }()
+		_, _ = h(context.Background(), nil)
+	}()
+	if l.inFlight != 0 {
+		t.Fatalf("inFlight = %d, want 0 after panic", l.inFlight)
+	}
+}
+
+func TestBBRInvalidOptions(t *testing.T) {
+	for _, opts := range [][]BBROption{
+		{WithBucket(0)},
+		{WithBucket(-1), WithWindow(-time.Second)},
+		{WithWindow(time.Nanosecond)},
+	} {
+		done, err := NewBBR(opts...).Allow()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		done(DoneInfo{})
+	}
+}
+
+func TestLimiterCache(t *testing.T) {
+	now := time.Now()
+	c := newLimiterCache(2, time.Minute, func() Limiter {
+		return NewTokenBucket(0, 1)
+	})
+	c.now = func() time.Time { return now }
+	a := c.get("a")
+	c.get("b")
+	if c.get("a") != a {
+		t.Fatal("limiter should be reused")
+	}
+	// 超过容量时淘汰最久未使用的 b
+	c.get("c")
+	if n := c.len(); n != 2 {
+		t.Fatalf("len = %d, want 2", n)
+	}
+	if c.get("a") != a {
+		t.Fatal("recently used key should be kept")
+	}
+
+	// 空闲超过 ttl 的 key 被淘汰
+	now = now.Add(2 * time.Minute)
+	if c.get("a") == a {
+		t.Fatal("expired key should get a new limiter")
+	}
+	if n := c.len(); n != 1 {
+		t.Fatalf("len = %d, want 1", n)
+	}
+
+	for i := 0; i < 100; i++ {
+		c.get(strconv.Itoa(i))
+	}
+	if n := c.len(); n != 2 {
+		t.Fatalf("len = %d, want 2", n)
+	}
+}
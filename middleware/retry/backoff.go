@@ -0,0 +1,53 @@
+// Package retry
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package retry
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff 返回第 attempt 次重试前需要等待的时间，attempt 从 1 开始
+type Backoff func(attempt int) time.Duration
+
+// Exponential 带随机抖动的指数退避，等待时间在 [0, min(max, base*2^(attempt-1))) 之间随机
+func Exponential(base, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		d := base << (attempt - 1)
+		if d <= 0 || d > max {
+			d = max
+		}
+		if d <= 0 {
+			return 0
+		}
+		return time.Duration(rand.Int63n(int64(d)))
+	}
+}
+
+// Constant 固定等待时间
+func Constant(d time.Duration) Backoff {
+	return func(int) time.Duration {
+		return d
+	}
+}
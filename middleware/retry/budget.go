@@ -0,0 +1,63 @@
+// Package retry
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package retry
+
+import (
+	"time"
+
+	"github.com/go-fox/fox/internal/window"
+)
+
+// Budget 重试预算，限制窗口内重试请求占全部请求的比例，避免重试放大故障
+type Budget struct {
+	ratio      float64
+	minRetries float64
+	requests   *window.RollingCounter
+	retries    *window.RollingCounter
+}
+
+// NewBudget 创建重试预算，窗口内的重试次数不超过 ratio*请求数 + minPerSecond*窗口秒数
+func NewBudget(ratio float64, minPerSecond int, ttl time.Duration) *Budget {
+	const buckets = 10
+	return &Budget{
+		ratio:      ratio,
+		minRetries: float64(minPerSecond) * ttl.Seconds(),
+		requests:   window.NewRollingCounter(buckets, ttl/buckets),
+		retries:    window.NewRollingCounter(buckets, ttl/buckets),
+	}
+}
+
+// Request 记录一次请求
+func (b *Budget) Request() {
+	b.requests.Add(1)
+}
+
+// Withdraw 申请一次重试，预算不足时返回 false
+func (b *Budget) Withdraw() bool {
+	if float64(b.retries.Count()) >= b.ratio*float64(b.requests.Count())+b.minRetries {
+		return false
+	}
+	b.retries.Add(1)
+	return true
+}
@@ -0,0 +1,299 @@
+// Package retry
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package retry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/go-fox/fox/errors"
+	"github.com/go-fox/fox/middleware"
+	"github.com/go-fox/fox/selector"
+	"github.com/go-fox/fox/transport"
+)
+
+// Option 重试中间件配置
+type Option func(o *options)
+
+type options struct {
+	attempts   int
+	backoff    Backoff
+	budget     *Budget
+	codes      map[int32]struct{}
+	reasons    map[string]struct{}
+	hedge      time.Duration
+	idempotent map[string]struct{}
+}
+
+// WithAttempts 最大尝试次数，包含第一次请求，默认 3
+func WithAttempts(attempts int) Option {
+	return func(o *options) {
+		o.attempts = attempts
+	}
+}
+
+// WithBackoff 重试退避策略，默认 Exponential(50ms, 1s)
+func WithBackoff(b Backoff) Option {
+	return func(o *options) {
+		o.backoff = b
+	}
+}
+
+// WithBudget 重试预算，默认重试不超过请求数的 20%，每秒至少允许 10 次重试
+func WithBudget(b *Budget) Option {
+	return func(o *options) {
+		o.budget = b
+	}
+}
+
+// WithCodes 可以重试的错误码，默认 503 和 grpc Unavailable
+func WithCodes(cs ...int) Option {
+	return func(o *options) {
+		o.codes = make(map[int32]struct{}, len(cs))
+		for _, c := range cs {
+			o.codes[int32(c)] = struct{}{}
+		}
+	}
+}
+
+// WithReasons 可以重试的错误原因，例如 no_available_node
+func WithReasons(reasons ...string) Option {
+	return func(o *options) {
+		o.reasons = make(map[string]struct{}, len(reasons))
+		for _, reason := range reasons {
+			o.reasons[reason] = struct{}{}
+		}
+	}
+}
+
+// WithHedging 对幂等的 operation 开启请求对冲，请求超过 delay 仍未返回时向其他节点并发发送请求，
+// 使用最先成功的结果。并发的请求共享 req，每次请求需要独立的 transport 和 reply，
+// 所以只对实现了 Concurrent 的 transport 生效，其他 transport 退化为顺序重试
+func WithHedging(delay time.Duration, operations ...string) Option {
+	return func(o *options) {
+		o.hedge = delay
+		o.idempotent = make(map[string]struct{}, len(operations))
+		for _, operation := range operations {
+			o.idempotent[operation] = struct{}{}
+		}
+	}
+}
+
+// Concurrent transport.Transporter 的可选接口，实现后同一次调用的 handler 可以被并发调用，
+// 对冲时每次请求使用 Fork 复制出的 transport，请求头等请求状态相互独立，
+// handler 需要为 Fork 出的 transport 使用独立的 reply
+type Concurrent interface {
+	Fork() transport.Transporter
+}
+
+// Client 客户端重试中间件，每次尝试都会通过 selector 重新选择节点，并尽量避开已经失败的节点
+func Client(opts ...Option) middleware.Middleware {
+	o := &options{
+		attempts: 3,
+		backoff:  Exponential(50*time.Millisecond, time.Second),
+		budget:   NewBudget(0.2, 10, 10*time.Second),
+		codes: map[int32]struct{}{
+			503:                      {},
+			int32(codes.Unavailable): {},
+		},
+		reasons: map[string]struct{}{},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			o.budget.Request()
+			r := &retrier{opts: o, handler: handler}
+			if o.hedge > 0 {
+				if tr, ok := transport.FromClientContext(ctx); ok {
+					c, ok := tr.(Concurrent)
+					if _, idempotent := o.idempotent[tr.Operation()]; ok && idempotent {
+						return r.hedge(ctx, req, c)
+					}
+				}
+			}
+			return r.retry(ctx, req)
+		}
+	}
+}
+
+// retrier 单次调用的重试状态
+type retrier struct {
+	opts    *options
+	handler middleware.Handler
+	mu      sync.Mutex // 保护对冲时并发写入的 tried
+	tried   []string
+}
+
+// retry 顺序重试
+func (r *retrier) retry(ctx context.Context, req interface{}) (reply interface{}, err error) {
+	for attempt := 0; attempt < r.opts.attempts; attempt++ {
+		if attempt > 0 {
+			if !r.retryable(err) || !r.opts.budget.Withdraw() {
+				return reply, err
+			}
+			if wait := r.opts.backoff(attempt); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return reply, err
+				case <-timer.C:
+				}
+			}
+		}
+		reply, err = r.attempt(ctx, req)
+		if err == nil {
+			return reply, nil
+		}
+	}
+	return reply, err
+}
+
+// hedge 对冲请求，节点被选中时立即记录，后续的对冲请求会避开已经选中的节点
+func (r *retrier) hedge(ctx context.Context, req interface{}, c Concurrent) (interface{}, error) {
+	type result struct {
+		reply interface{}
+		err   error
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan result, r.opts.attempts)
+	launch := func() {
+		p := &selector.Peer{Selected: r.record}
+		actx := transport.NewClientContext(ctx, c.Fork())
+		actx = selector.NewFilterContext(selector.NewPeerContext(actx, p), r.excludeTried())
+		go func() {
+			reply, err := r.handler(actx, req)
+			results <- result{reply: reply, err: err}
+		}()
+	}
+	launch()
+	inFlight, launched := 1, 1
+	timer := time.NewTimer(r.opts.hedge)
+	defer timer.Stop()
+	var last result
+	for inFlight > 0 {
+		select {
+		case <-timer.C:
+			if launched < r.opts.attempts && r.opts.budget.Withdraw() {
+				launch()
+				inFlight++
+				launched++
+				timer.Reset(r.opts.hedge)
+			}
+		case res := <-results:
+			inFlight--
+			if res.err == nil {
+				return res.reply, nil
+			}
+			last = res
+			// 不可重试的错误直接返回
+			if !r.retryable(res.err) {
+				return res.reply, res.err
+			}
+			if inFlight == 0 && launched < r.opts.attempts && r.opts.budget.Withdraw() {
+				launch()
+				inFlight++
+				launched++
+				timer.Reset(r.opts.hedge)
+			}
+		}
+	}
+	return last.reply, last.err
+}
+
+// record 记录被选中的节点
+func (r *retrier) record(node selector.Node) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tried = append(r.tried, node.Address())
+}
+
+// excludeTried 排除选择节点时已经记录的节点
+func (r *retrier) excludeTried() selector.NodeFilter {
+	return func(ctx context.Context, nodes []selector.Node) []selector.Node {
+		r.mu.Lock()
+		tried := append([]string(nil), r.tried...)
+		r.mu.Unlock()
+		return r.exclude(tried)(ctx, nodes)
+	}
+}
+
+// attempt 发起一次请求，并记录选中的节点
+func (r *retrier) attempt(ctx context.Context, req interface{}) (interface{}, error) {
+	p := &selector.Peer{}
+	ctx = selector.NewPeerContext(ctx, p)
+	if len(r.tried) > 0 {
+		ctx = selector.NewFilterContext(ctx, r.exclude(r.tried))
+	}
+	reply, err := r.handler(ctx, req)
+	if p.Node != nil {
+		r.tried = append(r.tried, p.Node.Address())
+	}
+	return reply, err
+}
+
+// retryable 错误是否可以重试
+func (r *retrier) retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	se := errors.FromError(err)
+	if _, ok := r.opts.codes[se.Code]; ok {
+		return true
+	}
+	_, ok := r.opts.reasons[se.Reason]
+	return ok
+}
+
+// exclude 排除已经尝试过的节点，所有节点都尝试过时不做排除
+func (r *retrier) exclude(tried []string) selector.NodeFilter {
+	return func(ctx context.Context, nodes []selector.Node) []selector.Node {
+		if len(tried) == 0 {
+			return nodes
+		}
+		filtered := make([]selector.Node, 0, len(nodes))
+		for _, n := range nodes {
+			excluded := false
+			for _, addr := range tried {
+				if n.Address() == addr {
+					excluded = true
+					break
+				}
+			}
+			if !excluded {
+				filtered = append(filtered, n)
+			}
+		}
+		if len(filtered) == 0 {
+			return nodes
+		}
+		return filtered
+	}
+}
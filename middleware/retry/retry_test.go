@@ -0,0 +1,167 @@
+package retry
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-fox/fox/errors"
+	"github.com/go-fox/fox/selector"
+	"github.com/go-fox/fox/selector/balancer/random"
+	"github.com/go-fox/fox/selector/base"
+	"github.com/go-fox/fox/transport"
+)
+
+func TestClient_Retry(t *testing.T) {
+	var calls int32
+	m := Client(WithAttempts(3), WithBackoff(Constant(0)))
+	h := m(func(ctx context.Context, req interface{}) (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			return nil, selector.ErrNoAvailable
+		}
+		return "ok", nil
+	})
+	reply, err := h(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reply != "ok" || calls != 3 {
+		t.Fatalf("reply = %v, calls = %d", reply, calls)
+	}
+}
+
+func TestClient_NotRetryable(t *testing.T) {
+	var calls int32
+	m := Client(WithAttempts(3), WithBackoff(Constant(0)))
+	h := m(func(ctx context.Context, req interface{}) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, errors.BadRequest("BAD_REQUEST", "bad request")
+	})
+	if _, err := h(context.Background(), nil); !errors.IsBadRequest(err) {
+		t.Fatalf("err = %v, want bad request", err)
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	b := NewBudget(0.5, 0, time.Second)
+	for i := 0; i < 4; i++ {
+		b.Request()
+	}
+	withdrawn := 0
+	for i := 0; i < 4; i++ {
+		if b.Withdraw() {
+			withdrawn++
+		}
+	}
+	if withdrawn != 2 {
+		t.Fatalf("withdrawn = %d, want 2", withdrawn)
+	}
+}
+
+type testTransport struct {
+	transport.Transporter
+}
+
+func (tr *testTransport) Operation() string { return "/test.v1.Test/Get" }
+
+// concurrentTransport 支持对冲的 transport
+type concurrentTransport struct {
+	testTransport
+}
+
+func (tr *concurrentTransport) Fork() transport.Transporter { return &concurrentTransport{} }
+
+type call struct {
+	node  string
+	start time.Duration
+}
+
+// hedgeHandler 模拟通过 selector 选择节点的 transport，第一次调用在 slow 后返回，之后的调用立即返回
+type hedgeHandler struct {
+	sel   selector.Selector
+	slow  time.Duration
+	begin time.Time
+	mu    sync.Mutex
+	calls []call
+}
+
+func newHedgeHandler(slow time.Duration) *hedgeHandler {
+	sel := selector.Get(random.Name).Build()
+	sel.Store([]selector.Node{
+		base.NewNode("http", "127.0.0.1:8000", nil),
+		base.NewNode("http", "127.0.0.1:8001", nil),
+	})
+	return &hedgeHandler{sel: sel, slow: slow, begin: time.Now()}
+}
+
+func (h *hedgeHandler) handle(ctx context.Context, req interface{}) (interface{}, error) {
+	node, done, err := h.sel.Select(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done(ctx, selector.DoneInfo{})
+	h.mu.Lock()
+	first := len(h.calls) == 0
+	h.calls = append(h.calls, call{node: node.Address(), start: time.Since(h.begin)})
+	h.mu.Unlock()
+	if !first {
+		return node.Address(), nil
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(h.slow):
+		return node.Address(), nil
+	}
+}
+
+func (h *hedgeHandler) records() []call {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]call(nil), h.calls...)
+}
+
+func TestClient_Hedging(t *testing.T) {
+	handler := newHedgeHandler(time.Second)
+	h := Client(WithHedging(50*time.Millisecond, "/test.v1.Test/Get"))(handler.handle)
+	ctx := transport.NewClientContext(context.Background(), &concurrentTransport{})
+	start := time.Now()
+	reply, err := h(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	elapsed := time.Since(start)
+	records := handler.records()
+	if len(records) != 2 {
+		t.Fatalf("calls = %v, want 2", records)
+	}
+	// 对冲请求在 delay 后发出，并避开慢请求已经选中的节点
+	if records[1].start < 50*time.Millisecond || elapsed > 500*time.Millisecond {
+		t.Fatalf("hedge started at %v, elapsed %v", records[1].start, elapsed)
+	}
+	if records[0].node == records[1].node {
+		t.Fatalf("hedge picked the same node %s", records[0].node)
+	}
+	// 最先成功的结果胜出
+	if reply != records[1].node {
+		t.Fatalf("reply = %v, want %s", reply, records[1].node)
+	}
+}
+
+func TestClient_HedgingNotConcurrent(t *testing.T) {
+	handler := newHedgeHandler(100 * time.Millisecond)
+	h := Client(WithHedging(10*time.Millisecond, "/test.v1.Test/Get"))(handler.handle)
+	// transport 不支持并发调用时不对冲
+	ctx := transport.NewClientContext(context.Background(), &testTransport{})
+	if _, err := h(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if records := handler.records(); len(records) != 1 {
+		t.Fatalf("calls = %v, want 1", records)
+	}
+}
@@ -0,0 +1,127 @@
+// Package tracing
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/go-fox/fox/errors"
+	"github.com/go-fox/fox/transport"
+)
+
+// Tracer 封装 otel tracer，负责 span 的创建、传播和结束
+type Tracer struct {
+	tracer trace.Tracer
+	kind   trace.SpanKind
+	opt    *options
+}
+
+// NewTracer 创建 Tracer
+func NewTracer(kind trace.SpanKind, opts ...Option) *Tracer {
+	o := &options{
+		propagator: propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+		name:       "fox",
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.tracerProvider == nil {
+		o.tracerProvider = otel.GetTracerProvider()
+	}
+	return &Tracer{
+		tracer: o.tracerProvider.Tracer(o.name),
+		kind:   kind,
+		opt:    o,
+	}
+}
+
+// Start 开始 span，服务端从 carrier 中提取上游的链路信息，客户端将链路信息注入 carrier
+func (t *Tracer) Start(ctx context.Context, operation string, carrier propagation.TextMapCarrier) (context.Context, trace.Span) {
+	if t.kind == trace.SpanKindServer {
+		ctx = t.opt.propagator.Extract(ctx, carrier)
+	}
+	ctx, span := t.tracer.Start(ctx, operation, trace.WithSpanKind(t.kind))
+	if t.kind == trace.SpanKindClient {
+		t.opt.propagator.Inject(ctx, carrier)
+	}
+	return ctx, span
+}
+
+// End 结束 span，根据错误设置 span 状态
+func (t *Tracer) End(_ context.Context, span trace.Span, err error) {
+	if err != nil {
+		se := errors.FromError(err)
+		span.RecordError(err)
+		span.SetAttributes(
+			attribute.Int("error.code", int(se.Code)),
+			attribute.String("error.reason", se.Reason),
+		)
+		span.SetStatus(codes.Error, se.Message)
+	} else {
+		span.SetStatus(codes.Ok, "OK")
+	}
+	span.End()
+}
+
+// setSpanAttributes 设置 transport 相关的 span 属性
+func setSpanAttributes(span trace.Span, tr transport.Transporter, server bool) {
+	attrs := []attribute.KeyValue{
+		attribute.String("transport.kind", tr.Kind().String()),
+		attribute.String("transport.operation", tr.Operation()),
+		attribute.String("transport.endpoint", tr.Endpoint()),
+	}
+	if server {
+		if addr := tr.RemoteAddr(); addr != nil {
+			attrs = append(attrs, attribute.String("net.peer.addr", addr.String()))
+		}
+	}
+	span.SetAttributes(attrs...)
+}
+
+// attributePeer 客户端选中的节点
+func attributePeer(addr string) attribute.KeyValue {
+	return attribute.String("net.peer.addr", addr)
+}
+
+// TraceID 获取上下文中的 trace id
+func TraceID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
+
+// SpanID 获取上下文中的 span id
+func SpanID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasSpanID() {
+		return sc.SpanID().String()
+	}
+	return ""
+}
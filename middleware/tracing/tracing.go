@@ -0,0 +1,102 @@
+// Package tracing
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/go-fox/fox/middleware"
+	"github.com/go-fox/fox/selector"
+	"github.com/go-fox/fox/transport"
+)
+
+// Option 链路追踪配置
+type Option func(o *options)
+
+type options struct {
+	tracerProvider trace.TracerProvider
+	propagator     propagation.TextMapPropagator
+	name           string
+}
+
+// WithTracerProvider 设置 TracerProvider，默认使用 otel.GetTracerProvider()
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracerProvider = provider
+	}
+}
+
+// WithPropagator 设置链路信息传播方式，默认使用 W3C trace context 和 baggage
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(o *options) {
+		o.propagator = propagator
+	}
+}
+
+// WithTracerName 设置 tracer 名称
+func WithTracerName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// Server 服务端链路追踪中间件
+func Server(opts ...Option) middleware.Middleware {
+	tracer := NewTracer(trace.SpanKindServer, opts...)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				var span trace.Span
+				ctx, span = tracer.Start(ctx, tr.Operation(), tr.RequestHeader())
+				setSpanAttributes(span, tr, true)
+				defer func() { tracer.End(ctx, span, err) }()
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+// Client 客户端链路追踪中间件
+func Client(opts ...Option) middleware.Middleware {
+	tracer := NewTracer(trace.SpanKindClient, opts...)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
+			if tr, ok := transport.FromClientContext(ctx); ok {
+				var span trace.Span
+				ctx, span = tracer.Start(ctx, tr.Operation(), tr.RequestHeader())
+				setSpanAttributes(span, tr, false)
+				defer func() {
+					if p, ok := selector.FromPeerContext(ctx); ok && p.Node != nil {
+						span.SetAttributes(attributePeer(p.Node.Address()))
+					}
+					tracer.End(ctx, span, err)
+				}()
+			}
+			return handler(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/go-fox/fox/errors"
+	"github.com/go-fox/fox/transport"
+)
+
+type headerCarrier map[string]string
+
+func (h headerCarrier) Get(key string) string        { return h[key] }
+func (h headerCarrier) Set(key string, value string) { h[key] = value }
+func (h headerCarrier) Add(key string, value string) { h[key] = value }
+func (h headerCarrier) Values(key string) []string   { return []string{h[key]} }
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type testTransport struct {
+	kind      transport.Kind
+	operation string
+	header    headerCarrier
+}
+
+func (tr *testTransport) Kind() transport.Kind            { return tr.kind }
+func (tr *testTransport) RemoteAddr() net.Addr            { return nil }
+func (tr *testTransport) Endpoint() string                { return "grpc://127.0.0.1:9000" }
+func (tr *testTransport) Operation() string               { return tr.operation }
+func (tr *testTransport) RequestHeader() transport.Header { return tr.header }
+func (tr *testTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	header := headerCarrier{}
+	operation := "/test.v1.Test/Hello"
+
+	server := Server(WithTracerProvider(provider))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errors.NotFound("USER_NOT_FOUND", "user not found")
+	})
+	client := Client(WithTracerProvider(provider))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		// 模拟服务端收到请求
+		ctx = transport.NewServerContext(context.Background(), &testTransport{kind: "grpc", operation: operation, header: header})
+		return server(ctx, req)
+	})
+	ctx := transport.NewClientContext(context.Background(), &testTransport{kind: "grpc", operation: operation, header: header})
+	if _, err := client(ctx, nil); !errors.IsNotFound(err) {
+		t.Fatalf("err = %v, want not found", err)
+	}
+	if header.Get("traceparent") == "" {
+		t.Fatal("traceparent should be injected into request header")
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("len(spans) = %d, want 2", len(spans))
+	}
+	serverSpan, clientSpan := spans[0], spans[1]
+	if serverSpan.SpanContext.TraceID() != clientSpan.SpanContext.TraceID() {
+		t.Fatal("server span should share trace id with client span")
+	}
+	if serverSpan.Parent.SpanID() != clientSpan.SpanContext.SpanID() {
+		t.Fatal("server span should be a child of client span")
+	}
+	if serverSpan.Status.Code != codes.Error || serverSpan.Status.Description != "user not found" {
+		t.Fatalf("server span status = %+v", serverSpan.Status)
+	}
+}
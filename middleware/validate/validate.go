@@ -0,0 +1,254 @@
+// Package validate
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package validate
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/go-fox/fox/errors"
+	"github.com/go-fox/fox/middleware"
+)
+
+// Reason 校验失败时返回错误的 reason
+const Reason = "VALIDATOR"
+
+// validator protoc-gen-validate 生成的 Validate 方法
+type validator interface {
+	Validate() error
+}
+
+// allValidator protoc-gen-validate 生成的 ValidateAll 方法，会返回所有字段的错误
+type allValidator interface {
+	ValidateAll() error
+}
+
+// multiError ValidateAll 返回的错误
+type multiError interface {
+	AllErrors() []error
+}
+
+// fieldError 单个字段的校验错误
+type fieldError interface {
+	Field() string
+	Reason() string
+}
+
+// ViolationsFunc 从校验错误中提取每个字段的错误信息，写入 errors.Error 的 Metadata
+type ViolationsFunc func(err error) map[string]string
+
+// Option 校验中间件配置
+type Option func(o *options)
+
+type options struct {
+	all           bool
+	protoValidate func(msg proto.Message) error
+	violations    ViolationsFunc
+}
+
+// WithValidateAll 是否优先使用 ValidateAll 返回所有字段的错误，默认 true
+func WithValidateAll(all bool) Option {
+	return func(o *options) {
+		o.all = all
+	}
+}
+
+// WithProtoValidator 使用 protovalidate 等基于规则的校验器校验 proto 消息，例如
+//
+//	v, _ := protovalidate.New()
+//	validate.Server(validate.WithProtoValidator(func(msg proto.Message) error { return v.Validate(msg) }))
+func WithProtoValidator(fn func(msg proto.Message) error) Option {
+	return func(o *options) {
+		o.protoValidate = fn
+	}
+}
+
+// WithViolations 设置字段错误的提取方法，默认支持 protoc-gen-validate 生成的错误和 protovalidate 的 *protovalidate.ValidationError
+func WithViolations(fn ViolationsFunc) Option {
+	return func(o *options) {
+		o.violations = fn
+	}
+}
+
+// Server 请求参数校验中间件，校验失败时返回 errors.BadRequest，Metadata 中为每个字段的错误信息
+func Server(opts ...Option) middleware.Middleware {
+	o := &options{
+		all:        true,
+		violations: Violations,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if err := o.validate(req); err != nil {
+				return nil, errors.BadRequest(Reason, err.Error()).WithMetadata(o.violations(err)).WithCause(err)
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+// validate 校验请求
+func (o *options) validate(req interface{}) error {
+	if v, ok := req.(allValidator); ok && o.all {
+		if err := v.ValidateAll(); err != nil {
+			return err
+		}
+	} else if v, ok := req.(validator); ok {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.protoValidate != nil {
+		if msg, ok := req.(proto.Message); ok {
+			return o.protoValidate(msg)
+		}
+	}
+	return nil
+}
+
+// Violations 提取 protoc-gen-validate 或 protovalidate 错误中每个字段的错误信息，没有字段错误时返回 nil
+func Violations(err error) map[string]string {
+	if md := protoViolations(err); md != nil {
+		return md
+	}
+	errs := []error{err}
+	if me, ok := err.(multiError); ok {
+		errs = me.AllErrors()
+	}
+	var md map[string]string
+	for _, e := range errs {
+		if fe, ok := e.(fieldError); ok {
+			if md == nil {
+				md = make(map[string]string, len(errs))
+			}
+			md[fe.Field()] = fe.Reason()
+		}
+	}
+	return md
+}
+
+// protoViolations 提取 protovalidate 错误中每个字段的错误信息，
+// *protovalidate.ValidationError 的 ToProto 方法返回 buf.validate.Violations，通过反射读取避免依赖 protovalidate
+func protoViolations(err error) map[string]string {
+	method := reflect.ValueOf(err).MethodByName("ToProto")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return nil
+	}
+	pb, ok := method.Call(nil)[0].Interface().(proto.Message)
+	if !ok || pb == nil {
+		return nil
+	}
+	msg := pb.ProtoReflect()
+	if !msg.IsValid() {
+		return nil
+	}
+	violations := msg.Descriptor().Fields().ByName("violations")
+	if violations == nil || !violations.IsList() || violations.Message() == nil {
+		return nil
+	}
+	list := msg.Get(violations).List()
+	var md map[string]string
+	for i := 0; i < list.Len(); i++ {
+		v := list.Get(i).Message()
+		field := fieldPath(v)
+		message := stringField(v, "message")
+		if md == nil {
+			md = make(map[string]string, list.Len())
+		}
+		// 同一字段有多条规则不满足时合并
+		if prev, ok := md[field]; ok {
+			message = prev + "; " + message
+		}
+		md[field] = message
+	}
+	return md
+}
+
+// fieldPath 把 buf.validate.FieldPath 转换为 a.b[0]["key"] 形式，兼容旧版本的 field_path 字符串
+func fieldPath(v protoreflect.Message) string {
+	fd := v.Descriptor().Fields().ByName("field")
+	if fd == nil || fd.Message() == nil || !v.Has(fd) {
+		return stringField(v, "field_path")
+	}
+	path := v.Get(fd).Message()
+	efd := path.Descriptor().Fields().ByName("elements")
+	if efd == nil || !efd.IsList() || efd.Message() == nil {
+		return ""
+	}
+	elements := path.Get(efd).List()
+	var b strings.Builder
+	for i := 0; i < elements.Len(); i++ {
+		e := elements.Get(i).Message()
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(stringField(e, "field_name"))
+		if subscript := subscriptOf(e); subscript != "" {
+			b.WriteString("[" + subscript + "]")
+		}
+	}
+	return b.String()
+}
+
+// subscriptOf 获取 buf.validate.FieldPathElement 中 repeated 和 map 字段的下标
+func subscriptOf(e protoreflect.Message) string {
+	oneof := e.Descriptor().Oneofs().ByName("subscript")
+	if oneof == nil {
+		return ""
+	}
+	fd := e.WhichOneof(oneof)
+	if fd == nil {
+		return ""
+	}
+	value := e.Get(fd)
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return strconv.Quote(value.String())
+	case protoreflect.BoolKind:
+		return strconv.FormatBool(value.Bool())
+	case protoreflect.Int32Kind, protoreflect.Int64Kind, protoreflect.Sint32Kind, protoreflect.Sint64Kind,
+		protoreflect.Sfixed32Kind, protoreflect.Sfixed64Kind:
+		return strconv.FormatInt(value.Int(), 10)
+	case protoreflect.Uint32Kind, protoreflect.Uint64Kind, protoreflect.Fixed32Kind, protoreflect.Fixed64Kind:
+		return strconv.FormatUint(value.Uint(), 10)
+	default:
+		return ""
+	}
+}
+
+// stringField 按名称读取字符串字段，字段不存在时返回空字符串
+func stringField(m protoreflect.Message, name protoreflect.Name) string {
+	fd := m.Descriptor().Fields().ByName(name)
+	if fd == nil || fd.Kind() != protoreflect.StringKind || fd.IsList() {
+		return ""
+	}
+	return m.Get(fd).String()
+}
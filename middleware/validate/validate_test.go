@@ -0,0 +1,213 @@
+package validate
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/go-fox/fox/errors"
+)
+
+type fieldErr struct {
+	field  string
+	reason string
+}
+
+func (e fieldErr) Error() string  { return e.field + ": " + e.reason }
+func (e fieldErr) Field() string  { return e.field }
+func (e fieldErr) Reason() string { return e.reason }
+
+type multiErr []error
+
+func (m multiErr) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (m multiErr) AllErrors() []error { return m }
+
+type request struct {
+	Name string
+	Age  int
+}
+
+func (r *request) Validate() error {
+	if r.Name == "" {
+		return fieldErr{"Name", "value is required"}
+	}
+	return nil
+}
+
+func (r *request) ValidateAll() error {
+	var errs multiErr
+	if r.Name == "" {
+		errs = append(errs, fieldErr{"Name", "value is required"})
+	}
+	if r.Age < 0 {
+		errs = append(errs, fieldErr{"Age", "value must be greater than or equal to 0"})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	h := Server()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	if _, err := h(context.Background(), &request{Name: "fox"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err := h(context.Background(), &request{Age: -1})
+	if !errors.IsBadRequest(err) {
+		t.Fatalf("err = %v, want bad request", err)
+	}
+	md := errors.FromError(err).Metadata
+	if len(md) != 2 || md["Name"] == "" || md["Age"] == "" {
+		t.Fatalf("unexpected metadata: %v", md)
+	}
+
+	h = Server(WithValidateAll(false))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	_, err = h(context.Background(), &request{Age: -1})
+	if md := errors.FromError(err).Metadata; len(md) != 1 || md["Name"] == "" {
+		t.Fatalf("unexpected metadata: %v", md)
+	}
+}
+
+// violationsFile 与 buf/validate/validate.proto 中 Violations 相关消息结构一致的描述
+var violationsFile = func() protoreflect.FileDescriptor {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string, repeated bool, oneof *int32) *descriptorpb.FieldDescriptorProto {
+		label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		if repeated {
+			label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		}
+		fd := &descriptorpb.FieldDescriptorProto{
+			Name:       proto.String(name),
+			Number:     proto.Int32(number),
+			Label:      label.Enum(),
+			Type:       typ.Enum(),
+			OneofIndex: oneof,
+		}
+		if typeName != "" {
+			fd.TypeName = proto.String(typeName)
+		}
+		return fd
+	}
+	msgType := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	strType := descriptorpb.FieldDescriptorProto_TYPE_STRING
+	subscript := proto.Int32(0)
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("buf/validate/validate_test.proto"),
+		Package: proto.String("buf.validate"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name:  proto.String("Violations"),
+				Field: []*descriptorpb.FieldDescriptorProto{field("violations", 1, msgType, ".buf.validate.Violation", true, nil)},
+			},
+			{
+				Name: proto.String("Violation"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("rule_id", 2, strType, "", false, nil),
+					field("message", 3, strType, "", false, nil),
+					field("field", 5, msgType, ".buf.validate.FieldPath", false, nil),
+				},
+			},
+			{
+				Name:  proto.String("FieldPath"),
+				Field: []*descriptorpb.FieldDescriptorProto{field("elements", 1, msgType, ".buf.validate.FieldPathElement", true, nil)},
+			},
+			{
+				Name: proto.String("FieldPathElement"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("field_name", 2, strType, "", false, nil),
+					field("index", 6, descriptorpb.FieldDescriptorProto_TYPE_UINT64, "", false, subscript),
+					field("string_key", 10, strType, "", false, subscript),
+				},
+				OneofDecl: []*descriptorpb.OneofDescriptorProto{{Name: proto.String("subscript")}},
+			},
+		},
+	}
+	fd, err := protodesc.NewFile(fdp, nil)
+	if err != nil {
+		panic(err)
+	}
+	return fd
+}()
+
+// protoValidationErr 模拟 *protovalidate.ValidationError
+type protoValidationErr struct {
+	violations proto.Message
+}
+
+func (e *protoValidationErr) Error() string { return "validation error" }
+
+func (e *protoValidationErr) ToProto() proto.Message { return e.violations }
+
+func newViolation(message string, elements ...func(protoreflect.Message)) protoreflect.Message {
+	msgs := violationsFile.Messages()
+	v := dynamicpb.NewMessage(msgs.ByName("Violation"))
+	v.Set(v.Descriptor().Fields().ByName("message"), protoreflect.ValueOfString(message))
+	path := dynamicpb.NewMessage(msgs.ByName("FieldPath"))
+	list := path.Mutable(path.Descriptor().Fields().ByName("elements")).List()
+	for _, set := range elements {
+		e := dynamicpb.NewMessage(msgs.ByName("FieldPathElement"))
+		set(e)
+		list.Append(protoreflect.ValueOfMessage(e))
+	}
+	v.Set(v.Descriptor().Fields().ByName("field"), protoreflect.ValueOfMessage(path))
+	return v
+}
+
+func element(name string, key string, index int) func(protoreflect.Message) {
+	return func(e protoreflect.Message) {
+		fields := e.Descriptor().Fields()
+		e.Set(fields.ByName("field_name"), protoreflect.ValueOfString(name))
+		if key != "" {
+			e.Set(fields.ByName("string_key"), protoreflect.ValueOfString(key))
+		}
+		if index >= 0 {
+			e.Set(fields.ByName("index"), protoreflect.ValueOfUint64(uint64(index)))
+		}
+	}
+}
+
+func TestProtoValidateViolations(t *testing.T) {
+	violations := dynamicpb.NewMessage(violationsFile.Messages().ByName("Violations"))
+	list := violations.Mutable(violations.Descriptor().Fields().ByName("violations")).List()
+	list.Append(protoreflect.ValueOfMessage(newViolation("value is required", element("name", "", -1))))
+	list.Append(protoreflect.ValueOfMessage(newViolation("value must be an email", element("users", "", 1), element("email", "", -1))))
+	list.Append(protoreflect.ValueOfMessage(newViolation("value length must be at most 8", element("labels", "env", -1))))
+	list.Append(protoreflect.ValueOfMessage(newViolation("value must be lowercase", element("labels", "env", -1))))
+
+	h := Server(WithProtoValidator(func(msg proto.Message) error {
+		return &protoValidationErr{violations: violations}
+	}))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	_, err := h(context.Background(), violations)
+	if !errors.IsBadRequest(err) {
+		t.Fatalf("err = %v, want bad request", err)
+	}
+	want := map[string]string{
+		"name":           "value is required",
+		"users[1].email": "value must be an email",
+		`labels["env"]`:  "value length must be at most 8; value must be lowercase",
+	}
+	if md := errors.FromError(err).Metadata; !reflect.DeepEqual(md, want) {
+		t.Fatalf("metadata = %v, want %v", md, want)
+	}
+}
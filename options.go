@@ -29,6 +29,7 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/go-fox/fox/health"
 	"github.com/go-fox/fox/registry"
 	"github.com/go-fox/fox/transport"
 )
@@ -52,22 +53,26 @@ type Option func(o *options)
 
 // Options options
 type options struct {
-	ctx              context.Context         // 应用上下文
-	id               string                  // 应用唯一标识
-	name             string                  // 应用名称
-	version          string                  // 应用版本
-	metadata         map[string]string       // 附加信息
-	endpoints        []*url.URL              // 服务地址
-	region           string                  // 服务所属地域
-	zone             string                  // 服务所属分区
-	hideBanner       bool                    // 隐藏打印横幅
-	maxProc          int64                   // 处理器内核优化
-	registrarTimeout time.Duration           // 服务注册超时时间
-	stopTimeout      time.Duration           // 注销服务超时时间
-	hooks            map[HookType][]HookFunc // 启动钩子
-	servers          []transport.Server      // 服务集合
-	registry         registry.Registry       // 注册中心
-	logger           *slog.Logger            // 日志组件
+	ctx               context.Context         // 应用上下文
+	id                string                  // 应用唯一标识
+	name              string                  // 应用名称
+	version           string                  // 应用版本
+	metadata          map[string]string       // 附加信息
+	endpoints         []*url.URL              // 服务地址
+	region            string                  // 服务所属地域
+	zone              string                  // 服务所属分区
+	hideBanner        bool                    // 隐藏打印横幅
+	maxProc           int64                   // 处理器内核优化
+	registrarTimeout  time.Duration           // 服务注册超时时间
+	stopTimeout       time.Duration           // 停止应用超时时间
+	drainDelay        time.Duration           // 注销服务后等待下线信息传播的时间
+	serverStopTimeout time.Duration           // 单个服务停止超时时间
+	hooks             map[HookType][]HookFunc // 启动钩子
+	servers           []transport.Server      // 服务集合
+	registry          registry.Registry       // 注册中心
+	logger            *slog.Logger            // 日志组件
+	health            *health.Health          // 健康状态
+	healthCheckers    map[string]health.Checker
 }
 
 // defaultOptions default options
@@ -85,6 +90,7 @@ func defaultOptions() *options {
 		hooks:            map[HookType][]HookFunc{},
 		servers:          []transport.Server{},
 		logger:           slog.With(slog.String("mod", ModName)),
+		health:           health.New(),
 	}
 }
 
@@ -158,6 +164,23 @@ func StopTimeout(timeout time.Duration) Option {
 	}
 }
 
+// DrainDelay with drain delay, the time to wait after deregistering so that
+// watchers and load balancers of clients notice this instance going away,
+// it is counted in the stop timeout
+func DrainDelay(delay time.Duration) Option {
+	return func(o *options) {
+		o.drainDelay = delay
+	}
+}
+
+// ServerStopTimeout with the deadline of stopping each server,
+// 0 means each server can use the remaining of stop timeout
+func ServerStopTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.serverStopTimeout = timeout
+	}
+}
+
 // Hooks with hooks options
 func Hooks(hookType HookType, hooks ...HookFunc) Option {
 	return func(o *options) {
@@ -205,3 +228,21 @@ func MaxProc(maxProc int64) Option {
 		o.maxProc = maxProc
 	}
 }
+
+// Health with health state shared by all servers
+func Health(h *health.Health) Option {
+	return func(o *options) {
+		o.health = h
+	}
+}
+
+// HealthCheck add a dependency check, readiness becomes not serving while it fails,
+// the checks are registered after all options are applied, so the order with Health does not matter
+func HealthCheck(name string, checker health.Checker) Option {
+	return func(o *options) {
+		if o.healthCheckers == nil {
+			o.healthCheckers = make(map[string]health.Checker)
+		}
+		o.healthCheckers[name] = checker
+	}
+}
@@ -0,0 +1,170 @@
+// Package file
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package file
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+
+	"github.com/go-fox/fox/codec/json"
+	"github.com/go-fox/fox/registry"
+	"github.com/go-fox/fox/registry/memory"
+)
+
+var _ registry.Discovery = (*Discovery)(nil)
+
+// Option 文件服务发现配置
+type Option func(d *Discovery)
+
+// WithLogger 设置日志
+func WithLogger(logger *slog.Logger) Option {
+	return func(d *Discovery) {
+		d.logger = logger
+	}
+}
+
+// Discovery 从 YAML 或 JSON 文件读取服务实例列表的服务发现，文件修改后自动重新加载，
+// 文件内容为 registry.ServiceInstance 数组，.yaml 和 .yml 后缀按 YAML 解析，其他按 JSON 解析
+type Discovery struct {
+	path   string
+	logger *slog.Logger
+	store  *memory.Registry
+	fw     *fsnotify.Watcher
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New 创建文件服务发现，文件不存在或格式错误时返回错误
+func New(path string, opts ...Option) (*Discovery, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	d := &Discovery{
+		path:   path,
+		logger: slog.With(slog.String("mod", "registry.file")),
+		store:  memory.New(),
+		done:   make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	if err = d.load(); err != nil {
+		return nil, err
+	}
+	d.fw, err = fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// 监听所在目录，编辑器保存时可能会删除并重建文件
+	if err = d.fw.Add(filepath.Dir(path)); err != nil {
+		_ = d.fw.Close()
+		return nil, err
+	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	go d.watch()
+	return d, nil
+}
+
+// GetService 获取服务实例列表
+func (d *Discovery) GetService(ctx context.Context, serviceName string) ([]*registry.ServiceInstance, error) {
+	return d.store.GetService(ctx, serviceName)
+}
+
+// Watch 监听服务
+func (d *Discovery) Watch(ctx context.Context, serviceName string) (registry.Watcher, error) {
+	return d.store.Watch(ctx, serviceName)
+}
+
+// Close 停止监听文件
+func (d *Discovery) Close() error {
+	d.cancel()
+	err := d.fw.Close()
+	<-d.done
+	return err
+}
+
+// watch 监听文件变化并重新加载
+func (d *Discovery) watch() {
+	defer close(d.done)
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case event, ok := <-d.fw.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != d.path || !event.Has(fsnotify.Write|fsnotify.Create) {
+				continue
+			}
+			// 文件格式错误时保留上一次加载的实例列表
+			if err := d.load(); err != nil {
+				d.logger.Error(fmt.Sprintf("[registry] reload %s error", d.path), "error", err)
+			}
+		case err, ok := <-d.fw.Errors:
+			if !ok {
+				return
+			}
+			d.logger.Error("[registry] watch file error", "error", err)
+		}
+	}
+}
+
+// load 加载文件
+func (d *Discovery) load() error {
+	data, err := os.ReadFile(d.path)
+	if err != nil {
+		return err
+	}
+	// 写入过程中可能读到空文件
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return fmt.Errorf("file %s is empty", d.path)
+	}
+	var services []*registry.ServiceInstance
+	switch strings.ToLower(filepath.Ext(d.path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &services)
+	default:
+		err = json.Codec{}.Unmarshal(data, &services)
+	}
+	if err != nil {
+		return err
+	}
+	for i, service := range services {
+		if service == nil || service.Name == "" {
+			return fmt.Errorf("service instance %d: %w", i, registry.ErrServiceInstanceNameEmpty)
+		}
+	}
+	d.store.Replace(services)
+	return nil
+}
@@ -0,0 +1,92 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiscovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "services.yaml")
+	writeFile(t, path, `
+- id: "1"
+  name: helloworld
+  version: v1.0.0
+  metadata:
+    zone: a
+  endpoints:
+    - http://127.0.0.1:8000
+`)
+	d, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	ctx := context.Background()
+	items, err := d.GetService(ctx, "helloworld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Version != "v1.0.0" || items[0].Metadata["zone"] != "a" || items[0].Endpoints[0] != "http://127.0.0.1:8000" {
+		t.Fatalf("GetService = %+v", items)
+	}
+
+	// Next 会阻塞，超时后通过 ctx 返回错误
+	watchCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	w, err := d.Watch(watchCtx, "helloworld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	if _, err = w.Next(); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, path, `
+- id: "1"
+  name: helloworld
+  endpoints: [http://127.0.0.1:8000]
+- id: "2"
+  name: helloworld
+  endpoints: [http://127.0.0.1:8001]
+`)
+	for {
+		items, err = w.Next()
+		if err != nil {
+			t.Fatalf("reload timeout: %v", err)
+		}
+		if len(items) == 2 {
+			break
+		}
+	}
+}
+
+func TestDiscoveryJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "services.json")
+	writeFile(t, path, `[{"id":"1","name":"helloworld","endpoints":["grpc://127.0.0.1:9000"]}]`)
+	d, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	items, err := d.GetService(context.Background(), "helloworld")
+	if err != nil || len(items) != 1 || items[0].Endpoints[0] != "grpc://127.0.0.1:9000" {
+		t.Fatalf("GetService = %v, %v", items, err)
+	}
+
+	writeFile(t, filepath.Join(filepath.Dir(path), "invalid.json"), `[{"id":"1"}]`)
+	if _, err = New(filepath.Join(filepath.Dir(path), "invalid.json")); err == nil {
+		t.Fatal("expected error for instance without name")
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
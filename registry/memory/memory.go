@@ -0,0 +1,237 @@
+// Package memory
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package memory
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/go-fox/fox/registry"
+)
+
+var (
+	_ registry.Registry  = (*Registry)(nil)
+	_ registry.Discovery = (*Registry)(nil)
+	_ registry.Watcher   = (*watcher)(nil)
+)
+
+// Registry 进程内的注册中心，用于本地开发和集成测试
+type Registry struct {
+	mu       sync.RWMutex
+	services map[string]map[string]*registry.ServiceInstance
+	watchers map[string]map[*watcher]struct{}
+}
+
+// New 创建进程内的注册中心
+func New() *Registry {
+	return &Registry{
+		services: make(map[string]map[string]*registry.ServiceInstance),
+		watchers: make(map[string]map[*watcher]struct{}),
+	}
+}
+
+// Register 注册服务，相同 ID 的实例会被覆盖
+func (r *Registry) Register(_ context.Context, service *registry.ServiceInstance) error {
+	if service == nil || service.Name == "" {
+		return registry.ErrServiceInstanceNameEmpty
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	instances, ok := r.services[service.Name]
+	if !ok {
+		instances = make(map[string]*registry.ServiceInstance)
+		r.services[service.Name] = instances
+	}
+	instances[service.ID] = clone(service)
+	r.notify(service.Name)
+	return nil
+}
+
+// Update 修改服务
+func (r *Registry) Update(ctx context.Context, service *registry.ServiceInstance) error {
+	return r.Register(ctx, service)
+}
+
+// Deregister 注销服务
+func (r *Registry) Deregister(_ context.Context, service *registry.ServiceInstance) error {
+	if service == nil || service.Name == "" {
+		return registry.ErrServiceInstanceNameEmpty
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	instances, ok := r.services[service.Name]
+	if !ok {
+		return nil
+	}
+	if _, ok = instances[service.ID]; !ok {
+		return nil
+	}
+	delete(instances, service.ID)
+	if len(instances) == 0 {
+		delete(r.services, service.Name)
+	}
+	r.notify(service.Name)
+	return nil
+}
+
+// Replace 使用 services 替换全部服务实例，只通知实例发生变化的服务
+func (r *Registry) Replace(services []*registry.ServiceInstance) {
+	next := make(map[string]map[string]*registry.ServiceInstance)
+	for _, service := range services {
+		if service == nil || service.Name == "" {
+			continue
+		}
+		instances, ok := next[service.Name]
+		if !ok {
+			instances = make(map[string]*registry.ServiceInstance)
+			next[service.Name] = instances
+		}
+		instances[service.ID] = clone(service)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	prev := r.services
+	r.services = next
+	for name, instances := range next {
+		if !reflect.DeepEqual(prev[name], instances) {
+			r.notify(name)
+		}
+	}
+	for name := range prev {
+		if _, ok := next[name]; !ok {
+			r.notify(name)
+		}
+	}
+}
+
+// GetService 获取服务实例列表，按实例 ID 排序，返回的实例是副本
+func (r *Registry) GetService(_ context.Context, serviceName string) ([]*registry.ServiceInstance, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.list(serviceName), nil
+}
+
+// Watch 监听服务，第一次调用 Watcher.Next 立即返回当前的实例列表，之后在实例变化时返回
+func (r *Registry) Watch(ctx context.Context, serviceName string) (registry.Watcher, error) {
+	w := &watcher{
+		r:           r,
+		serviceName: serviceName,
+		event:       make(chan struct{}, 1),
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	// 第一次 Next 返回当前的实例列表
+	w.event <- struct{}{}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ws, ok := r.watchers[serviceName]
+	if !ok {
+		ws = make(map[*watcher]struct{})
+		r.watchers[serviceName] = ws
+	}
+	ws[w] = struct{}{}
+	// ctx 取消后移除监听者，避免未调用 Stop 的监听者一直留在 watchers 中
+	context.AfterFunc(w.ctx, func() {
+		r.removeWatcher(w)
+	})
+	return w, nil
+}
+
+// list 获取服务实例列表，调用方需持有锁
+func (r *Registry) list(serviceName string) []*registry.ServiceInstance {
+	instances := r.services[serviceName]
+	items := make([]*registry.ServiceInstance, 0, len(instances))
+	for _, ins := range instances {
+		items = append(items, clone(ins))
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ID < items[j].ID
+	})
+	return items
+}
+
+// clone 复制服务实例，避免调用方修改注册中心保存的实例
+func clone(ins *registry.ServiceInstance) *registry.ServiceInstance {
+	c := *ins
+	if ins.Metadata != nil {
+		c.Metadata = make(map[string]string, len(ins.Metadata))
+		for k, v := range ins.Metadata {
+			c.Metadata[k] = v
+		}
+	}
+	if ins.Endpoints != nil {
+		c.Endpoints = append([]string(nil), ins.Endpoints...)
+	}
+	return &c
+}
+
+// notify 通知服务的监听者，调用方需持有写锁
+func (r *Registry) notify(serviceName string) {
+	for w := range r.watchers[serviceName] {
+		// 监听者未处理的通知会合并
+		select {
+		case w.event <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// removeWatcher 移除监听者
+func (r *Registry) removeWatcher(w *watcher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ws := r.watchers[w.serviceName]
+	delete(ws, w)
+	if len(ws) == 0 {
+		delete(r.watchers, w.serviceName)
+	}
+}
+
+type watcher struct {
+	r           *Registry
+	ctx         context.Context
+	cancel      context.CancelFunc
+	serviceName string
+	event       chan struct{}
+}
+
+// Next 阻塞直到服务实例发生变化
+func (w *watcher) Next() ([]*registry.ServiceInstance, error) {
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case <-w.event:
+	}
+	w.r.mu.RLock()
+	defer w.r.mu.RUnlock()
+	return w.r.list(w.serviceName), nil
+}
+
+// Stop 停止监听
+func (w *watcher) Stop() error {
+	w.cancel()
+	w.r.removeWatcher(w)
+	return nil
+}
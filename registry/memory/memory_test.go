@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-fox/fox/registry"
+)
+
+func next(t *testing.T, w registry.Watcher) []*registry.ServiceInstance {
+	t.Helper()
+	type result struct {
+		items []*registry.ServiceInstance
+		err   error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		items, err := w.Next()
+		ch <- result{items, err}
+	}()
+	select {
+	case res := <-ch:
+		if res.err != nil {
+			t.Fatal(res.err)
+		}
+		return res.items
+	case <-time.After(time.Second):
+		t.Fatal("watcher next timeout")
+	}
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	r := New()
+	w, err := r.Watch(ctx, "helloworld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	if items := next(t, w); len(items) != 0 {
+		t.Fatalf("first next = %v, want empty", items)
+	}
+
+	ins1 := &registry.ServiceInstance{ID: "1", Name: "helloworld", Endpoints: []string{"http://127.0.0.1:8000"}}
+	ins2 := &registry.ServiceInstance{ID: "2", Name: "helloworld", Endpoints: []string{"http://127.0.0.1:8001"}}
+	if err = r.Register(ctx, ins1); err != nil {
+		t.Fatal(err)
+	}
+	if items := next(t, w); len(items) != 1 || items[0].ID != "1" {
+		t.Fatalf("next after register = %v", items)
+	}
+	// 其他服务的变化不通知
+	if err = r.Register(ctx, &registry.ServiceInstance{ID: "3", Name: "other"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Register(ctx, ins2); err != nil {
+		t.Fatal(err)
+	}
+	if items := next(t, w); len(items) != 2 || items[0].ID != "1" || items[1].ID != "2" {
+		t.Fatalf("next after register = %v", items)
+	}
+	if err = r.Deregister(ctx, ins1); err != nil {
+		t.Fatal(err)
+	}
+	if items := next(t, w); len(items) != 1 || items[0].ID != "2" {
+		t.Fatalf("next after deregister = %v", items)
+	}
+	items, err := r.GetService(ctx, "helloworld")
+	if err != nil || len(items) != 1 {
+		t.Fatalf("GetService = %v, %v", items, err)
+	}
+
+	if err = r.Register(ctx, &registry.ServiceInstance{ID: "4"}); err != registry.ErrServiceInstanceNameEmpty {
+		t.Fatalf("register without name error = %v", err)
+	}
+
+	if err = w.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Next(); err != context.Canceled {
+		t.Fatalf("next after stop error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	ctx := context.Background()
+	r := New()
+	r.Replace([]*registry.ServiceInstance{
+		{ID: "1", Name: "a"},
+		{ID: "2", Name: "b"},
+	})
+	wa, _ := r.Watch(ctx, "a")
+	defer wa.Stop()
+	wb, _ := r.Watch(ctx, "b")
+	defer wb.Stop()
+	next(t, wa)
+	next(t, wb)
+
+	r.Replace([]*registry.ServiceInstance{
+		{ID: "1", Name: "a"},
+		{ID: "3", Name: "b"},
+	})
+	if items := next(t, wb); len(items) != 1 || items[0].ID != "3" {
+		t.Fatalf("b = %v", items)
+	}
+	// a 没有变化，不会收到通知
+	select {
+	case <-wa.(*watcher).event:
+		t.Fatal("unchanged service notified")
+	default:
+	}
+}
+
+func TestWatchContextCancel(t *testing.T) {
+	r := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := r.Watch(ctx, "helloworld"); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for {
+		r.mu.RLock()
+		n := len(r.watchers)
+		r.mu.RUnlock()
+		if n == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("watcher not removed after ctx cancel")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestInstanceCopy(t *testing.T) {
+	ctx := context.Background()
+	r := New()
+	ins := &registry.ServiceInstance{ID: "1", Name: "helloworld", Metadata: map[string]string{"zone": "a"}, Endpoints: []string{"http://127.0.0.1:8000"}}
+	if err := r.Register(ctx, ins); err != nil {
+		t.Fatal(err)
+	}
+	ins.Metadata["zone"] = "b"
+	items, _ := r.GetService(ctx, "helloworld")
+	items[0].Endpoints[0] = "http://127.0.0.1:9000"
+	items[0].Version = "v2"
+
+	items, _ = r.GetService(ctx, "helloworld")
+	if got := items[0]; got.Metadata["zone"] != "a" || got.Endpoints[0] != "http://127.0.0.1:8000" || got.Version != "" {
+		t.Fatalf("stored instance modified: %+v", got)
+	}
+}
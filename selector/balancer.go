@@ -34,3 +34,9 @@ type Balancer interface {
 type BalancerBuilder interface {
 	Build() Balancer
 }
+
+// BalancerUpdater is an optional interface of Balancer, Update is called with all nodes on Selector.Store,
+// it can be used to prebuild the data structures, the nodes passed to Pick may be a filtered subset
+type BalancerUpdater interface {
+	Update(nodes []WeightedNode)
+}
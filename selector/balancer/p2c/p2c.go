@@ -0,0 +1,104 @@
+// Package p2c
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package p2c
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-fox/fox/selector"
+	"github.com/go-fox/fox/selector/base"
+	"github.com/go-fox/fox/selector/node/ewma"
+)
+
+const (
+	// Name selector name
+	Name = "p2c"
+	// forcePick 落选节点超过该时间未被选中时强制选择一次，用于刷新它的统计数据
+	forcePick = time.Second
+)
+
+var _ selector.Balancer = (*balancer)(nil)
+var _ selector.BalancerBuilder = (*balancerBuilder)(nil)
+
+func init() {
+	selector.Register(
+		base.NewSelectorBuilder(
+			Name,
+			&ewma.Builder{},
+			&balancerBuilder{},
+		),
+	)
+}
+
+// balancer power of two choices, randomly pick two nodes and choose the one with higher weight
+type balancer struct {
+	mu     sync.Mutex
+	r      *rand.Rand
+	picked int32
+}
+
+func (b *balancer) Pick(ctx context.Context, nodes []selector.WeightedNode) (selector.WeightedNode, selector.DoneFunc, error) {
+	if len(nodes) == 0 {
+		return nil, nil, selector.ErrNoAvailable
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nodes[0].Pick(), nil
+	}
+	nodeA, nodeB := b.prePick(nodes)
+	selected, unselected := nodeA, nodeB
+	if nodeB.Weight() > nodeA.Weight() {
+		selected, unselected = nodeB, nodeA
+	}
+	// 同一时间只允许一个请求强制选择落选节点，Pick 更新 lastPick 后才释放标记
+	if unselected.PickElapsed() > forcePick && atomic.CompareAndSwapInt32(&b.picked, 0, 1) {
+		defer atomic.StoreInt32(&b.picked, 0)
+		// 抢到标记后再次检查，避免其他请求刚完成强制选择
+		if unselected.PickElapsed() > forcePick {
+			selected = unselected
+		}
+	}
+	return selected, selected.Pick(), nil
+}
+
+// prePick 随机选择两个不同的节点
+func (b *balancer) prePick(nodes []selector.WeightedNode) (selector.WeightedNode, selector.WeightedNode) {
+	b.mu.Lock()
+	a := b.r.Intn(len(nodes))
+	c := b.r.Intn(len(nodes) - 1)
+	b.mu.Unlock()
+	if c >= a {
+		c++
+	}
+	return nodes[a], nodes[c]
+}
+
+type balancerBuilder struct{}
+
+func (b *balancerBuilder) Build() selector.Balancer {
+	return &balancer{r: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
@@ -0,0 +1,91 @@
+package p2c
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-fox/fox/errors"
+	"github.com/go-fox/fox/selector"
+	"github.com/go-fox/fox/selector/base"
+)
+
+func TestP2C(t *testing.T) {
+	s := selector.Get(Name).Build()
+	s.Store([]selector.Node{
+		base.NewNode("http", "127.0.0.1:8000", nil),
+		base.NewNode("http", "127.0.0.1:8001", nil),
+		base.NewNode("http", "127.0.0.1:8002", nil),
+	})
+	picks := make(map[string]int)
+	for i := 0; i < 300; i++ {
+		node, done, err := s.Select(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		picks[node.Address()]++
+		var doneErr error
+		if node.Address() == "127.0.0.1:8000" {
+			doneErr = errors.ServiceUnavailable("UNAVAILABLE", "node down")
+		}
+		done(context.Background(), selector.DoneInfo{Err: doneErr})
+	}
+	if n := picks["127.0.0.1:8000"]; n > 10 {
+		t.Fatalf("failing node picked %d times, picks = %v", n, picks)
+	}
+	if picks["127.0.0.1:8001"] == 0 || picks["127.0.0.1:8002"] == 0 {
+		t.Fatalf("healthy nodes not picked, picks = %v", picks)
+	}
+}
+
+type stubNode struct {
+	selector.Node
+	weight   float64
+	lastPick int64
+}
+
+func (n *stubNode) Raw() selector.Node { return n.Node }
+
+func (n *stubNode) Weight() float64 { return n.weight }
+
+func (n *stubNode) Pick() selector.DoneFunc {
+	atomic.StoreInt64(&n.lastPick, time.Now().UnixNano())
+	return func(context.Context, selector.DoneInfo) {}
+}
+
+func (n *stubNode) PickElapsed() time.Duration {
+	return time.Duration(time.Now().UnixNano() - atomic.LoadInt64(&n.lastPick))
+}
+
+func TestP2CForcePickOnce(t *testing.T) {
+	now := time.Now().UnixNano()
+	fast := &stubNode{Node: base.NewNode("http", "127.0.0.1:8000", nil), weight: 10, lastPick: now}
+	slow := &stubNode{Node: base.NewNode("http", "127.0.0.1:8001", nil), weight: 1}
+	nodes := []selector.WeightedNode{fast, slow}
+	b := (&balancerBuilder{}).Build()
+
+	var (
+		wg     sync.WaitGroup
+		forced int32
+	)
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			node, _, err := b.Pick(context.Background(), nodes)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if node == slow {
+				atomic.AddInt32(&forced, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if forced != 1 {
+		t.Fatalf("slow node forced %d times, want 1", forced)
+	}
+}
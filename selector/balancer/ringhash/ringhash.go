@@ -0,0 +1,268 @@
+// Package ringhash
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package ringhash
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/go-fox/fox/selector"
+	"github.com/go-fox/fox/selector/base"
+	"github.com/go-fox/fox/selector/node/direct"
+	"github.com/go-fox/fox/transport"
+)
+
+const (
+	// Name selector name
+	Name = "ringhash"
+	// DefaultHeader 默认读取 hash key 的请求头
+	DefaultHeader = "x-md-hash-key"
+
+	defaultWeight     = 100
+	defaultReplicas   = 160
+	defaultLoadFactor = 1.25
+)
+
+var _ selector.Balancer = (*balancer)(nil)
+var _ selector.BalancerUpdater = (*balancer)(nil)
+var _ selector.BalancerBuilder = (*balancerBuilder)(nil)
+
+func init() {
+	selector.Register(NewBuilder())
+}
+
+type keyContext struct{}
+
+// NewKeyContext 设置请求的 hash key，相同 key 的请求会路由到同一个节点
+func NewKeyContext(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, keyContext{}, key)
+}
+
+// FromKeyContext 获取请求的 hash key
+func FromKeyContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(keyContext{}).(string)
+	return key, ok
+}
+
+// Option ringhash option
+type Option func(o *options)
+
+type options struct {
+	name       string
+	header     string
+	replicas   int
+	loadFactor float64
+}
+
+// WithName 设置注册的选择器名称，默认为 ringhash
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithHeader 设置读取 hash key 的请求头，context 中没有 hash key 时使用
+func WithHeader(header string) Option {
+	return func(o *options) {
+		o.header = header
+	}
+}
+
+// WithReplicas 设置权重为 100 的节点在环上的虚拟节点数量
+func WithReplicas(replicas int) Option {
+	return func(o *options) {
+		o.replicas = replicas
+	}
+}
+
+// WithLoadFactor 设置负载上限系数，节点的进行中请求超过平均值的 factor 倍时顺延到环上的下一个节点
+func WithLoadFactor(factor float64) Option {
+	return func(o *options) {
+		o.loadFactor = factor
+	}
+}
+
+// NewBuilder 创建一致性 hash 选择器，自定义参数时可以通过 WithName 使用其他名称注册，
+// grpc 客户端只能使用 grpc 包初始化时已注册的选择器，其他名称的选择器需要在 init 中调用 grpc.RegisterBalancer 注册
+func NewBuilder(opts ...Option) selector.Builder {
+	o := options{
+		name:       Name,
+		header:     DefaultHeader,
+		replicas:   defaultReplicas,
+		loadFactor: defaultLoadFactor,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.loadFactor < 1 {
+		o.loadFactor = 1
+	}
+	return base.NewSelectorBuilder(o.name, &direct.Builder{}, &balancerBuilder{opts: o})
+}
+
+// ring 一致性 hash 环
+type ring struct {
+	hashes []uint64
+	nodes  []string // 虚拟节点对应的节点地址
+}
+
+func (r *ring) Len() int           { return len(r.hashes) }
+func (r *ring) Less(i, j int) bool { return r.hashes[i] < r.hashes[j] }
+func (r *ring) Swap(i, j int) {
+	r.hashes[i], r.hashes[j] = r.hashes[j], r.hashes[i]
+	r.nodes[i], r.nodes[j] = r.nodes[j], r.nodes[i]
+}
+
+// balancer consistent hash with bounded load
+type balancer struct {
+	opts  options
+	mu    sync.Mutex
+	ring  *ring
+	load  map[string]int64 // 每个节点进行中的请求数
+	total int64
+}
+
+// Update 节点变化时重建 hash 环，Pick 时只在环上查找，不再重建
+func (b *balancer) Update(nodes []selector.WeightedNode) {
+	r := b.build(nodes)
+	b.mu.Lock()
+	b.ring = r
+	b.mu.Unlock()
+}
+
+func (b *balancer) Pick(ctx context.Context, nodes []selector.WeightedNode) (selector.WeightedNode, selector.DoneFunc, error) {
+	if len(nodes) == 0 {
+		return nil, nil, selector.ErrNoAvailable
+	}
+	key := b.hashKey(ctx)
+	b.mu.Lock()
+	var selected selector.WeightedNode
+	if key == "" {
+		// 没有 hash key 时随机选择
+		selected = nodes[rand.Intn(len(nodes))]
+	} else {
+		selected = b.lookup(nodes, key)
+	}
+	address := selected.Address()
+	b.load[address]++
+	b.total++
+	b.mu.Unlock()
+
+	done := selected.Pick()
+	return selected, func(ctx context.Context, di selector.DoneInfo) {
+		b.mu.Lock()
+		if b.load[address]--; b.load[address] <= 0 {
+			delete(b.load, address)
+		}
+		b.total--
+		b.mu.Unlock()
+		done(ctx, di)
+	}, nil
+}
+
+// hashKey 优先使用 context 中的 hash key，其次使用请求头
+func (b *balancer) hashKey(ctx context.Context) string {
+	if key, ok := FromKeyContext(ctx); ok {
+		return key
+	}
+	if tr, ok := transport.FromClientContext(ctx); ok && b.opts.header != "" {
+		return tr.RequestHeader().Get(b.opts.header)
+	}
+	return ""
+}
+
+// lookup 从 key 在环上的位置顺时针查找第一个在候选列表中且未超过负载上限的节点，
+// 被过滤或驱逐的节点直接跳过，调用方需持有锁
+func (b *balancer) lookup(nodes []selector.WeightedNode, key string) selector.WeightedNode {
+	r := b.ring
+	if r == nil {
+		// 没有通过 Update 设置节点时使用候选节点创建 hash 环
+		r = b.build(nodes)
+	}
+	candidates := make(map[string]selector.WeightedNode, len(nodes))
+	for _, node := range nodes {
+		candidates[node.Address()] = node
+	}
+	h := hash(key)
+	idx := sort.Search(len(r.hashes), func(i int) bool {
+		return r.hashes[i] >= h
+	})
+	// 负载上限为平均负载的 loadFactor 倍，总容量一定大于当前负载，所以必然能找到节点
+	capacity := int64(math.Ceil(float64(b.total+1) * b.opts.loadFactor / float64(len(nodes))))
+	var first selector.WeightedNode
+	for i := 0; i < len(r.hashes); i++ {
+		node, ok := candidates[r.nodes[(idx+i)%len(r.hashes)]]
+		if !ok {
+			continue
+		}
+		if b.load[node.Address()] < capacity {
+			return node
+		}
+		if first == nil {
+			first = node
+		}
+	}
+	if first != nil {
+		return first
+	}
+	// 候选节点都不在环上时随机选择
+	return nodes[rand.Intn(len(nodes))]
+}
+
+// build 根据节点权重创建 hash 环
+func (b *balancer) build(nodes []selector.WeightedNode) *ring {
+	r := &ring{}
+	for _, node := range nodes {
+		replicas := int(math.Ceil(float64(b.opts.replicas) * node.Weight() / defaultWeight))
+		replicas = max(replicas, 1)
+		for i := 0; i < replicas; i++ {
+			r.hashes = append(r.hashes, hash(node.Address()+"#"+strconv.Itoa(i)))
+			r.nodes = append(r.nodes, node.Address())
+		}
+	}
+	sort.Sort(r)
+	return r
+}
+
+func hash(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return h.Sum64()
+}
+
+type balancerBuilder struct {
+	opts options
+}
+
+func (b *balancerBuilder) Build() selector.Balancer {
+	return &balancer{
+		opts: b.opts,
+		load: make(map[string]int64),
+	}
+}
@@ -0,0 +1,101 @@
+package ringhash
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/go-fox/fox/selector"
+	"github.com/go-fox/fox/selector/base"
+)
+
+func newNodes(addrs ...string) []selector.Node {
+	nodes := make([]selector.Node, 0, len(addrs))
+	for _, addr := range addrs {
+		nodes = append(nodes, base.NewNode("http", addr, nil))
+	}
+	return nodes
+}
+
+func pick(t *testing.T, s selector.Selector, key string) string {
+	node, done, err := s.Select(NewKeyContext(context.Background(), key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	done(context.Background(), selector.DoneInfo{})
+	return node.Address()
+}
+
+func TestRingHash(t *testing.T) {
+	s := selector.Get(Name).Build()
+	s.Store(newNodes("10.0.0.1:80", "10.0.0.2:80", "10.0.0.3:80"))
+	before := make(map[string]string)
+	for i := 0; i < 100; i++ {
+		key := "user-" + strconv.Itoa(i)
+		before[key] = pick(t, s, key)
+		if got := pick(t, s, key); got != before[key] {
+			t.Fatalf("key %s picked %s then %s", key, before[key], got)
+		}
+	}
+
+	// 节点下线后，只有原来在下线节点上的 key 会迁移
+	s.Store(newNodes("10.0.0.1:80", "10.0.0.2:80"))
+	for key, addr := range before {
+		got := pick(t, s, key)
+		if addr != "10.0.0.3:80" && got != addr {
+			t.Fatalf("key %s moved from %s to %s", key, addr, got)
+		}
+	}
+}
+
+func TestRingHashBoundedLoad(t *testing.T) {
+	s := selector.Get(Name).Build()
+	s.Store(newNodes("10.0.0.1:80", "10.0.0.2:80", "10.0.0.3:80"))
+	ctx := NewKeyContext(context.Background(), "hot-key")
+	load := make(map[string]int)
+	for i := 0; i < 30; i++ {
+		node, _, err := s.Select(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		load[node.Address()]++
+	}
+	for addr, n := range load {
+		if n > 13 {
+			t.Fatalf("node %s load %d exceeds bound, load = %v", addr, n, load)
+		}
+	}
+}
+
+func TestRingHashFilteredNodes(t *testing.T) {
+	s := selector.Get(Name).Build()
+	s.Store(newNodes("10.0.0.1:80", "10.0.0.2:80", "10.0.0.3:80"))
+	before := make(map[string]string)
+	for i := 0; i < 100; i++ {
+		key := "user-" + strconv.Itoa(i)
+		before[key] = pick(t, s, key)
+	}
+	// 过滤掉的节点在环上被跳过，其他 key 的位置不变
+	exclude := selector.WithNodeFilter(func(_ context.Context, nodes []selector.Node) []selector.Node {
+		res := make([]selector.Node, 0, len(nodes))
+		for _, n := range nodes {
+			if n.Address() != "10.0.0.3:80" {
+				res = append(res, n)
+			}
+		}
+		return res
+	})
+	for key, addr := range before {
+		node, done, err := s.Select(NewKeyContext(context.Background(), key), exclude)
+		if err != nil {
+			t.Fatal(err)
+		}
+		done(context.Background(), selector.DoneInfo{})
+		if node.Address() == "10.0.0.3:80" {
+			t.Fatalf("key %s picked filtered node", key)
+		}
+		if addr != "10.0.0.3:80" && node.Address() != addr {
+			t.Fatalf("key %s moved from %s to %s", key, addr, node.Address())
+		}
+	}
+}
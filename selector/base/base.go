@@ -25,15 +25,32 @@ package base
 
 import "github.com/go-fox/fox/selector"
 
+// Option is selector builder option
+type Option func(b *baseBuilder)
+
+// WithOutlier with outlier detection config, nil disables outlier detection,
+// the config set by SetOutlierConfig is used by default, which is disabled unless set
+func WithOutlier(conf *OutlierConfig) Option {
+	return func(b *baseBuilder) {
+		b.outlier = conf
+		b.outlierSet = true
+	}
+}
+
 // NewSelectorBuilder is creating a selector builder
 func NewSelectorBuilder(
 	name string,
 	nodeBuilder selector.WeightedNodeBuilder,
 	balancer selector.BalancerBuilder,
+	opts ...Option,
 ) selector.Builder {
-	return &baseBuilder{
+	b := &baseBuilder{
 		name:        name,
 		nodeBuilder: nodeBuilder,
 		balancer:    balancer,
 	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
 }
@@ -0,0 +1,255 @@
+// Package base
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package base
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-fox/fox/selector"
+)
+
+// OutlierHook 节点被驱逐和恢复时的回调，可以用于上报监控指标
+type OutlierHook interface {
+	// OnEject 节点被驱逐，duration 为本次驱逐的时长
+	OnEject(node selector.Node, duration time.Duration)
+	// OnReadmit 节点驱逐到期后恢复
+	OnReadmit(node selector.Node)
+}
+
+// OutlierConfig 被动健康检查配置，根据请求结果驱逐连续失败或失败率过高的节点
+type OutlierConfig struct {
+	ConsecutiveErrors  int                  // 连续失败次数达到后驱逐，0 表示不检查
+	ErrorRate          float64              // 统计窗口内的失败率达到后驱逐，取值 (0, 1]，0 表示不检查
+	MinRequests        int                  // 统计失败率需要的最少请求数
+	Interval           time.Duration        // 失败率的统计窗口
+	BaseEjectionTime   time.Duration        // 第 n 次驱逐的时长为 BaseEjectionTime * 2^(n-1)
+	MaxEjectionTime    time.Duration        // 最长驱逐时长
+	MaxEjectionPercent float64              // 同时被驱逐的节点最多占全部节点的百分比，至少允许驱逐一个节点
+	ErrHandler         func(err error) bool // 判断错误是否为失败，默认为 selector.DefaultErrHandler
+	Logger             *slog.Logger         // 驱逐和恢复的日志
+	Hooks              []OutlierHook        // 驱逐和恢复的回调
+}
+
+// DefaultOutlierConfig 默认的被动健康检查配置
+func DefaultOutlierConfig() *OutlierConfig {
+	return &OutlierConfig{
+		ConsecutiveErrors:  5,
+		ErrorRate:          0.5,
+		MinRequests:        20,
+		Interval:           10 * time.Second,
+		BaseEjectionTime:   30 * time.Second,
+		MaxEjectionTime:    5 * time.Minute,
+		MaxEjectionPercent: 50,
+	}
+}
+
+var outlierConfig atomic.Pointer[OutlierConfig]
+
+// SetOutlierConfig 设置没有通过 WithOutlier 指定配置的选择器使用的被动健康检查配置，默认关闭，
+// nil 表示关闭，只对之后创建的选择器生效，例如 base.SetOutlierConfig(base.DefaultOutlierConfig())
+func SetOutlierConfig(conf *OutlierConfig) {
+	outlierConfig.Store(conf)
+}
+
+// outlierStat 节点的请求统计
+type outlierStat struct {
+	node         selector.Node
+	consecutive  int       // 连续失败次数
+	requests     int       // 统计窗口内的请求数
+	failures     int       // 统计窗口内的失败数
+	windowStart  time.Time // 统计窗口开始时间
+	ejections    int       // 驱逐次数，用于计算驱逐时长，健康的统计窗口会逐步减少
+	ejectedUntil time.Time // 驱逐到期时间，零值表示未被驱逐
+}
+
+// outlierDetector 被动健康检查
+type outlierDetector struct {
+	conf    OutlierConfig
+	mu      sync.Mutex
+	stats   map[string]*outlierStat
+	total   int   // 当前节点总数
+	ejected int32 // 当前被驱逐的节点数
+}
+
+func newOutlierDetector(conf *OutlierConfig) *outlierDetector {
+	d := &outlierDetector{
+		conf:  *conf,
+		stats: make(map[string]*outlierStat),
+	}
+	if d.conf.ErrHandler == nil {
+		d.conf.ErrHandler = selector.DefaultErrHandler
+	}
+	if d.conf.Logger == nil {
+		d.conf.Logger = slog.With(slog.String("mod", "selector"))
+	}
+	if d.conf.MaxEjectionTime < d.conf.BaseEjectionTime {
+		d.conf.MaxEjectionTime = d.conf.BaseEjectionTime
+	}
+	return d
+}
+
+// store 节点列表更新，清理已经下线节点的统计
+func (d *outlierDetector) store(nodes []selector.WeightedNode) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	alive := make(map[string]struct{}, len(nodes))
+	for _, n := range nodes {
+		alive[n.Address()] = struct{}{}
+	}
+	for addr, st := range d.stats {
+		if _, ok := alive[addr]; !ok {
+			if !st.ejectedUntil.IsZero() {
+				atomic.AddInt32(&d.ejected, -1)
+			}
+			delete(d.stats, addr)
+		}
+	}
+	d.total = len(nodes)
+}
+
+// filter 过滤被驱逐的节点，驱逐到期的节点恢复，全部被驱逐时返回原节点列表
+func (d *outlierDetector) filter(nodes []selector.WeightedNode) []selector.WeightedNode {
+	if atomic.LoadInt32(&d.ejected) == 0 {
+		return nodes
+	}
+	now := time.Now()
+	var readmitted []selector.Node
+	d.mu.Lock()
+	candidates := make([]selector.WeightedNode, 0, len(nodes))
+	for _, n := range nodes {
+		st, ok := d.stats[n.Address()]
+		if ok && !st.ejectedUntil.IsZero() {
+			if now.Before(st.ejectedUntil) {
+				continue
+			}
+			d.readmit(st, now)
+			readmitted = append(readmitted, st.node)
+		}
+		candidates = append(candidates, n)
+	}
+	d.mu.Unlock()
+	for _, n := range readmitted {
+		d.conf.Logger.Info(fmt.Sprintf("[selector] node %s readmitted", n.Address()), "service", n.ServiceName())
+		for _, hook := range d.conf.Hooks {
+			hook.OnReadmit(n)
+		}
+	}
+	if len(candidates) == 0 {
+		return nodes
+	}
+	return candidates
+}
+
+// readmit 恢复节点，调用方需持有锁
+func (d *outlierDetector) readmit(st *outlierStat, now time.Time) {
+	st.ejectedUntil = time.Time{}
+	st.consecutive, st.requests, st.failures = 0, 0, 0
+	st.windowStart = now
+	atomic.AddInt32(&d.ejected, -1)
+}
+
+// report 记录请求结果，达到阈值时驱逐节点
+func (d *outlierDetector) report(node selector.Node, err error) {
+	now := time.Now()
+	failure := d.conf.ErrHandler(err)
+	d.mu.Lock()
+	st, ok := d.stats[node.Address()]
+	if !ok {
+		st = &outlierStat{node: node, windowStart: now}
+		d.stats[node.Address()] = st
+	}
+	// 驱逐前发出的请求结束时不再统计
+	if !st.ejectedUntil.IsZero() {
+		d.mu.Unlock()
+		return
+	}
+	if d.conf.Interval > 0 && now.Sub(st.windowStart) >= d.conf.Interval {
+		if st.failures == 0 && st.ejections > 0 {
+			st.ejections--
+		}
+		st.requests, st.failures = 0, 0
+		st.windowStart = now
+	}
+	st.requests++
+	if failure {
+		st.consecutive++
+		st.failures++
+	} else {
+		st.consecutive = 0
+	}
+	if !d.shouldEject(st) || !d.allowEject() {
+		d.mu.Unlock()
+		return
+	}
+	st.ejections++
+	duration := d.conf.BaseEjectionTime << min(st.ejections-1, 30)
+	if duration <= 0 || duration > d.conf.MaxEjectionTime {
+		duration = d.conf.MaxEjectionTime
+	}
+	st.ejectedUntil = now.Add(duration)
+	st.node = node
+	atomic.AddInt32(&d.ejected, 1)
+	consecutive, failures, requests := st.consecutive, st.failures, st.requests
+	d.mu.Unlock()
+
+	d.conf.Logger.Warn(fmt.Sprintf("[selector] node %s ejected for %s", node.Address(), duration),
+		"service", node.ServiceName(),
+		"consecutive_errors", consecutive,
+		"failures", failures,
+		"requests", requests,
+	)
+	for _, hook := range d.conf.Hooks {
+		hook.OnEject(node, duration)
+	}
+}
+
+// shouldEject 是否达到驱逐阈值，调用方需持有锁
+func (d *outlierDetector) shouldEject(st *outlierStat) bool {
+	if d.conf.ConsecutiveErrors > 0 && st.consecutive >= d.conf.ConsecutiveErrors {
+		return true
+	}
+	return d.conf.ErrorRate > 0 && st.requests >= max(d.conf.MinRequests, 1) &&
+		float64(st.failures)/float64(st.requests) >= d.conf.ErrorRate
+}
+
+// allowEject 驱逐后是否超过最大驱逐比例，调用方需持有锁
+func (d *outlierDetector) allowEject() bool {
+	limit := max(int(float64(d.total)*d.conf.MaxEjectionPercent/100), 1)
+	return int(atomic.LoadInt32(&d.ejected)) < limit
+}
+
+// wrap 包装 done 回调，记录请求结果
+func (d *outlierDetector) wrap(node selector.Node, done selector.DoneFunc) selector.DoneFunc {
+	return func(ctx context.Context, info selector.DoneInfo) {
+		if done != nil {
+			done(ctx, info)
+		}
+		d.report(node, info.Err)
+	}
+}
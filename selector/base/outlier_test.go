@@ -0,0 +1,133 @@
+package base
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-fox/fox/errors"
+	"github.com/go-fox/fox/selector"
+	"github.com/go-fox/fox/selector/node/direct"
+)
+
+type testBalancer struct{ next int }
+
+func (b *testBalancer) Pick(_ context.Context, nodes []selector.WeightedNode) (selector.WeightedNode, selector.DoneFunc, error) {
+	wn := nodes[b.next%len(nodes)]
+	b.next++
+	return wn, wn.Pick(), nil
+}
+
+type testBalancerBuilder struct{}
+
+func (testBalancerBuilder) Build() selector.Balancer { return &testBalancer{} }
+
+type testHook struct{ ejected, readmitted []string }
+
+func (h *testHook) OnEject(node selector.Node, _ time.Duration) {
+	h.ejected = append(h.ejected, node.Address())
+}
+
+func (h *testHook) OnReadmit(node selector.Node) {
+	h.readmitted = append(h.readmitted, node.Address())
+}
+
+func TestOutlier(t *testing.T) {
+	hook := &testHook{}
+	conf := DefaultOutlierConfig()
+	conf.ConsecutiveErrors = 3
+	conf.BaseEjectionTime = 100 * time.Millisecond
+	conf.Hooks = []OutlierHook{hook}
+	s := NewSelectorBuilder("test", &direct.Builder{}, testBalancerBuilder{}, WithOutlier(conf)).Build()
+	s.Store([]selector.Node{
+		NewNode("http", "127.0.0.1:8000", nil),
+		NewNode("http", "127.0.0.1:8001", nil),
+		NewNode("http", "127.0.0.1:8002", nil),
+	})
+	// 8000 和 8001 持续失败，最多驱逐 50% 的节点，只有一个会被驱逐
+	down := map[string]bool{"127.0.0.1:8000": true, "127.0.0.1:8001": true}
+	picks := make(map[string]int)
+	for i := 0; i < 60; i++ {
+		node, done, err := s.Select(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doneErr error
+		if down[node.Address()] {
+			doneErr = errors.ServiceUnavailable("UNAVAILABLE", "node down")
+		}
+		picks[node.Address()]++
+		done(context.Background(), selector.DoneInfo{Err: doneErr})
+	}
+	if len(hook.ejected) != 1 || hook.ejected[0] != "127.0.0.1:8000" {
+		t.Fatalf("ejected = %v, want [127.0.0.1:8000]", hook.ejected)
+	}
+	if picks["127.0.0.1:8000"] != 3 {
+		t.Fatalf("ejected node picked %d times, picks = %v", picks["127.0.0.1:8000"], picks)
+	}
+
+	// 驱逐到期后恢复
+	time.Sleep(conf.BaseEjectionTime)
+	delete(down, "127.0.0.1:8000")
+	readmitted := false
+	for i := 0; i < 6; i++ {
+		node, done, err := s.Select(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		readmitted = readmitted || node.Address() == "127.0.0.1:8000"
+		done(context.Background(), selector.DoneInfo{})
+	}
+	if !readmitted || len(hook.readmitted) != 1 {
+		t.Fatalf("node not readmitted, readmitted = %v", hook.readmitted)
+	}
+}
+
+func TestOutlierDefaultDisabled(t *testing.T) {
+	builder := NewSelectorBuilder("test", &direct.Builder{}, testBalancerBuilder{})
+	if builder.Build().(*baseSelector).outlier != nil {
+		t.Fatal("outlier detection should be disabled by default")
+	}
+	SetOutlierConfig(DefaultOutlierConfig())
+	defer SetOutlierConfig(nil)
+	if builder.Build().(*baseSelector).outlier == nil {
+		t.Fatal("outlier detection should be enabled by SetOutlierConfig")
+	}
+	if NewSelectorBuilder("test", &direct.Builder{}, testBalancerBuilder{}, WithOutlier(nil)).Build().(*baseSelector).outlier != nil {
+		t.Fatal("WithOutlier(nil) should disable outlier detection")
+	}
+}
+
+func TestOutlierBeforeNodeFilter(t *testing.T) {
+	conf := DefaultOutlierConfig()
+	conf.ConsecutiveErrors = 1
+	s := NewSelectorBuilder("test", &direct.Builder{}, testBalancerBuilder{}, WithOutlier(conf)).Build()
+	s.Store([]selector.Node{
+		NewNode("http", "127.0.0.1:8000", nil),
+		NewNode("http", "127.0.0.1:8001", nil),
+	})
+	// 优先选择 8000，不存在时回退到全部节点，与分区过滤器的回退方式相同
+	prefer := func(_ context.Context, nodes []selector.Node) []selector.Node {
+		for _, n := range nodes {
+			if n.Address() == "127.0.0.1:8000" {
+				return []selector.Node{n}
+			}
+		}
+		return nodes
+	}
+	node, done, err := s.Select(context.Background(), selector.WithNodeFilter(prefer))
+	if err != nil || node.Address() != "127.0.0.1:8000" {
+		t.Fatalf("node = %v, err = %v", node, err)
+	}
+	done(context.Background(), selector.DoneInfo{Err: errors.ServiceUnavailable("UNAVAILABLE", "node down")})
+	for i := 0; i < 5; i++ {
+		node, done, err = s.Select(context.Background(), selector.WithNodeFilter(prefer))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if node.Address() != "127.0.0.1:8001" {
+			t.Fatalf("ejected node picked: %s", node.Address())
+		}
+		done(context.Background(), selector.DoneInfo{})
+	}
+}
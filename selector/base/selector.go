@@ -41,6 +41,7 @@ type baseSelector struct {
 	nodeBuilder selector.WeightedNodeBuilder
 	balancer    selector.Balancer
 	nodes       *satomic.Value[[]selector.WeightedNode]
+	outlier     *outlierDetector
 }
 
 func (b *baseSelector) Store(nodes []selector.Node) {
@@ -48,6 +49,12 @@ func (b *baseSelector) Store(nodes []selector.Node) {
 	for _, n := range nodes {
 		weightedNodes = append(weightedNodes, b.nodeBuilder.Build(n))
 	}
+	if b.outlier != nil {
+		b.outlier.store(weightedNodes)
+	}
+	if u, ok := b.balancer.(selector.BalancerUpdater); ok {
+		u.Update(weightedNodes)
+	}
 	b.nodes.Store(weightedNodes)
 }
 
@@ -57,9 +64,14 @@ func (b *baseSelector) Select(ctx context.Context, opts ...selector.SelectOption
 		candidates []selector.WeightedNode
 	)
 	nodes := b.nodes.Load()
+	// 先移除被驱逐的节点，节点过滤器只能看到未被驱逐的节点，例如同分区节点全部被驱逐时可以回退到其他分区
+	if b.outlier != nil {
+		nodes = b.outlier.filter(nodes)
+	}
 	for _, o := range opts {
 		o(&options)
 	}
+	options.NodeFilters = append(options.NodeFilters, selector.FromFilterContext(ctx)...)
 	if len(options.NodeFilters) > 0 {
 		newNodes := make([]selector.Node, len(nodes))
 		for i, wc := range nodes {
@@ -86,6 +98,12 @@ func (b *baseSelector) Select(ctx context.Context, opts ...selector.SelectOption
 	p, ok := selector.FromPeerContext(ctx)
 	if ok {
 		p.Node = wn.Raw()
+		if p.Selected != nil {
+			p.Selected(p.Node)
+		}
+	}
+	if b.outlier != nil {
+		done = b.outlier.wrap(wn.Raw(), done)
 	}
 	return wn.Raw(), done, nil
 }
@@ -94,6 +112,8 @@ type baseBuilder struct {
 	name        string
 	nodeBuilder selector.WeightedNodeBuilder
 	balancer    selector.BalancerBuilder
+	outlier     *OutlierConfig
+	outlierSet  bool
 }
 
 func (bb *baseBuilder) Name() string {
@@ -101,10 +121,18 @@ func (bb *baseBuilder) Name() string {
 }
 
 func (bb *baseBuilder) Build() selector.Selector {
-	return &baseSelector{
+	s := &baseSelector{
 		nodeBuilder: bb.nodeBuilder,
 		balancer:    bb.balancer.Build(),
 		name:        bb.name,
 		nodes:       satomic.New[[]selector.WeightedNode](),
 	}
+	conf := bb.outlier
+	if !bb.outlierSet {
+		conf = outlierConfig.Load()
+	}
+	if conf != nil {
+		s.outlier = newOutlierDetector(conf)
+	}
+	return s
 }
@@ -0,0 +1,55 @@
+// Package selector
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package selector
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	foxErrors "github.com/go-fox/fox/errors"
+)
+
+// DefaultErrHandler 服务端错误、超时和不可用视为失败，客户端错误和主动取消不影响成功率
+func DefaultErrHandler(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var fe *foxErrors.Error
+	if errors.As(err, &fe) {
+		return fe.Code >= 500
+	}
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.Unknown, codes.DeadlineExceeded, codes.ResourceExhausted,
+			codes.Internal, codes.Unavailable, codes.DataLoss:
+			return true
+		}
+		// 通过 grpc 传递的 fox 错误使用 http 状态码
+		return s.Code() >= 500
+	}
+	return true
+}
@@ -27,3 +27,19 @@ import "context"
 
 // NodeFilter node filter, if return false this node is delete
 type NodeFilter func(ctx context.Context, node []Node) []Node
+
+type filterKey struct{}
+
+// NewFilterContext returns a new context that carries node filters, they are applied by Select after the select options filters.
+func NewFilterContext(ctx context.Context, filters ...NodeFilter) context.Context {
+	if len(filters) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, filterKey{}, append(FromFilterContext(ctx), filters...))
+}
+
+// FromFilterContext returns the node filters stored in ctx, if any.
+func FromFilterContext(ctx context.Context) []NodeFilter {
+	filters, _ := ctx.Value(filterKey{}).([]NodeFilter)
+	return filters[:len(filters):len(filters)]
+}
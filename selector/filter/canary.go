@@ -0,0 +1,137 @@
+// Package filter
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package filter
+
+import (
+	"context"
+	"hash/fnv"
+	"math/rand"
+
+	"github.com/go-fox/fox/selector"
+	"github.com/go-fox/fox/transport"
+)
+
+// DefaultCanaryHeader 默认的灰度请求头，值为 true 时路由到灰度节点，为 false 时路由到稳定节点
+const DefaultCanaryHeader = "x-md-canary"
+
+// CanaryOption canary filter option
+type CanaryOption func(o *canaryOptions)
+
+type canaryOptions struct {
+	labelKey   string
+	labelValue string
+	header     string
+	hashHeader string
+	percent    float64
+}
+
+// WithCanaryLabel 设置标识灰度节点的元数据，默认为 canary=true
+func WithCanaryLabel(key, value string) CanaryOption {
+	return func(o *canaryOptions) {
+		o.labelKey = key
+		o.labelValue = value
+	}
+}
+
+// WithCanaryHeader 设置指定路由的请求头，默认为 DefaultCanaryHeader
+func WithCanaryHeader(header string) CanaryOption {
+	return func(o *canaryOptions) {
+		o.header = header
+	}
+}
+
+// WithCanaryPercent 设置没有指定路由的请求路由到灰度节点的百分比，取值 [0, 100]
+func WithCanaryPercent(percent float64) CanaryOption {
+	return func(o *canaryOptions) {
+		o.percent = percent
+	}
+}
+
+// WithCanaryHashHeader 设置按比例分流时使用的请求头，例如用户编号，相同的值固定路由到同一侧
+func WithCanaryHashHeader(header string) CanaryOption {
+	return func(o *canaryOptions) {
+		o.hashHeader = header
+	}
+}
+
+// Canary 灰度分流，请求头指定时按请求头路由，否则按比例路由到灰度节点或稳定节点，
+// 选中的一侧没有节点时回退到另一侧
+func Canary(opts ...CanaryOption) selector.NodeFilter {
+	o := canaryOptions{
+		labelKey:   "canary",
+		labelValue: "true",
+		header:     DefaultCanaryHeader,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(ctx context.Context, nodes []selector.Node) []selector.Node {
+		canary := make([]selector.Node, 0, len(nodes))
+		stable := make([]selector.Node, 0, len(nodes))
+		for _, n := range nodes {
+			if n.Metadata()[o.labelKey] == o.labelValue {
+				canary = append(canary, n)
+			} else {
+				stable = append(stable, n)
+			}
+		}
+		if len(canary) == 0 || len(stable) == 0 {
+			return nodes
+		}
+		if o.toCanary(ctx) {
+			return canary
+		}
+		return stable
+	}
+}
+
+// toCanary 请求是否路由到灰度节点
+func (o canaryOptions) toCanary(ctx context.Context) bool {
+	var header transport.Header
+	if tr, ok := transport.FromClientContext(ctx); ok {
+		header = tr.RequestHeader()
+	}
+	if header != nil && o.header != "" {
+		switch header.Get(o.header) {
+		case "true", "1":
+			return true
+		case "false", "0":
+			return false
+		}
+	}
+	if o.percent <= 0 {
+		return false
+	}
+	if o.percent >= 100 {
+		return true
+	}
+	if header != nil && o.hashHeader != "" {
+		if key := header.Get(o.hashHeader); key != "" {
+			h := fnv.New32a()
+			_, _ = h.Write([]byte(key))
+			return float64(h.Sum32()%10000) < o.percent*100
+		}
+	}
+	return rand.Float64()*100 < o.percent
+}
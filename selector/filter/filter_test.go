@@ -0,0 +1,155 @@
+package filter
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/go-fox/fox/errors"
+	"github.com/go-fox/fox/registry"
+	"github.com/go-fox/fox/selector"
+	"github.com/go-fox/fox/selector/balancer/random"
+	"github.com/go-fox/fox/selector/base"
+	"github.com/go-fox/fox/transport"
+)
+
+func newNode(addr, version string, md map[string]string) selector.Node {
+	return base.NewNode("http", addr, &registry.ServiceInstance{Version: version, Metadata: md})
+}
+
+func addresses(nodes []selector.Node) string {
+	addrs := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		addrs = append(addrs, n.Address())
+	}
+	return strings.Join(addrs, ",")
+}
+
+type header map[string]string
+
+func (h header) Get(key string) string      { return h[key] }
+func (h header) Set(key, value string)      { h[key] = value }
+func (h header) Add(key, value string)      { h[key] = value }
+func (h header) Keys() []string             { return nil }
+func (h header) Values(key string) []string { return []string{h[key]} }
+
+type testTransport struct {
+	header header
+}
+
+func (t *testTransport) Kind() transport.Kind            { return "test" }
+func (t *testTransport) RemoteAddr() net.Addr            { return nil }
+func (t *testTransport) Endpoint() string                { return "" }
+func (t *testTransport) Operation() string               { return "" }
+func (t *testTransport) RequestHeader() transport.Header { return t.header }
+func (t *testTransport) ReplyHeader() transport.Header   { return header{} }
+
+func TestConstraint(t *testing.T) {
+	tests := map[string]map[string]bool{
+		">=1.2.0 <2.0.0": {"v1.2.0": true, "1.9.9": true, "2.0.0": false, "1.1.9": false},
+		"^1.2":           {"1.2.0": true, "1.99.0": true, "2.0.0": false},
+		"^0.2.3":         {"0.2.3": true, "0.2.9": true, "0.3.0": false},
+		"~1.2.3":         {"1.2.9": true, "1.3.0": false},
+		"1.x || >= 3":    {"1.5.0": true, "2.0.0": false, "3.1.0": true},
+		"!=1.0.0":        {"1.0.0": false, "1.0.1": true, "bad": false},
+		">1.0.0-alpha":   {"1.0.0-beta": true, "1.0.0": true, "1.0.0-alpha": false},
+	}
+	for constraint, versions := range tests {
+		c, err := ParseConstraint(constraint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for version, want := range versions {
+			if got := c.Check(version); got != want {
+				t.Errorf("%q.Check(%q) = %v, want %v", constraint, version, got, want)
+			}
+		}
+	}
+	if _, err := ParseConstraint("?1.0"); err == nil {
+		t.Error("invalid constraint, want error")
+	}
+}
+
+func TestFilters(t *testing.T) {
+	nodes := []selector.Node{
+		newNode("a", "1.0.0", map[string]string{"region": "sh", "zone": "sh-1", "env": "prod"}),
+		newNode("b", "1.1.0", map[string]string{"region": "sh", "zone": "sh-2", "env": "prod", "gpu": ""}),
+		newNode("c", "2.0.0", map[string]string{"region": "bj", "zone": "bj-1", "env": "test", "canary": "true"}),
+	}
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		filter selector.NodeFilter
+		want   string
+	}{
+		{"version", Version("^1"), "a,b"},
+		{"same zone", Zone("sh", "sh-1"), "a"},
+		{"same region", Zone("sh", "sh-3"), "a,b"},
+		{"fallback", Zone("gz", "gz-1"), "a,b,c"},
+		{"min healthy", Zone("sh", "sh-1", WithMinHealthy(2)), "a,b"},
+		{"health check", Zone("sh", "sh-1", WithHealthCheck(func(n selector.Node) bool { return n.Address() != "a" })), "a,b"},
+		{"metadata", Metadata(map[string]string{"env": "prod"}), "a,b"},
+		{"label selector", LabelSelector("env in (prod, test), !canary, gpu"), "b"},
+		{"label not equal", LabelSelector("zone!=sh-1,env notin (test)"), "b"},
+		{"stable", Canary(), "a,b"},
+		{"canary percent", Canary(WithCanaryPercent(100)), "c"},
+	}
+	for _, tt := range tests {
+		if got := addresses(tt.filter(ctx, nodes)); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	// 请求头指定路由，以及按 hash 请求头固定分流
+	canaryCtx := transport.NewClientContext(ctx, &testTransport{header: header{DefaultCanaryHeader: "true"}})
+	if got := addresses(Canary()(canaryCtx, nodes)); got != "c" {
+		t.Errorf("canary header: got %s, want c", got)
+	}
+	userCtx := transport.NewClientContext(ctx, &testTransport{header: header{"x-md-uid": "1001"}})
+	filter := Canary(WithCanaryPercent(50), WithCanaryHashHeader("x-md-uid"))
+	first := addresses(filter(userCtx, nodes))
+	for i := 0; i < 10; i++ {
+		if got := addresses(filter(userCtx, nodes)); got != first {
+			t.Fatalf("hash header: got %s, want %s", got, first)
+		}
+	}
+}
+
+func TestZoneFallbackOnEjection(t *testing.T) {
+	conf := base.DefaultOutlierConfig()
+	conf.ConsecutiveErrors = 1
+	conf.MaxEjectionPercent = 100
+	base.SetOutlierConfig(conf)
+	s := selector.Get(random.Name).Build()
+	base.SetOutlierConfig(nil)
+	s.Store([]selector.Node{
+		newNode("a1", "1.0.0", map[string]string{"region": "sh", "zone": "sh-1"}),
+		newNode("a2", "1.0.0", map[string]string{"region": "sh", "zone": "sh-1"}),
+		newNode("b", "1.0.0", map[string]string{"region": "sh", "zone": "sh-2"}),
+	})
+	zone := selector.WithNodeFilter(Zone("sh", "sh-1"))
+	ctx := context.Background()
+	// 同分区的节点全部失败被驱逐
+	for ejected := map[string]bool{}; len(ejected) < 2; {
+		node, done, err := s.Select(ctx, zone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if node.Address() == "b" {
+			t.Fatal("picked other zone before same zone nodes were ejected")
+		}
+		ejected[node.Address()] = true
+		done(ctx, selector.DoneInfo{Err: errors.ServiceUnavailable("UNAVAILABLE", "node down")})
+	}
+	for i := 0; i < 10; i++ {
+		node, done, err := s.Select(ctx, zone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if node.Address() != "b" {
+			t.Fatalf("picked ejected node %s, want fallback to other zone", node.Address())
+		}
+		done(ctx, selector.DoneInfo{})
+	}
+}
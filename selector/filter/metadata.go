@@ -0,0 +1,190 @@
+// Package filter
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package filter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-fox/fox/selector"
+)
+
+// Metadata 按元数据过滤节点，labels 全部相等的节点才会保留
+func Metadata(labels map[string]string) selector.NodeFilter {
+	return func(_ context.Context, nodes []selector.Node) []selector.Node {
+		filtered := make([]selector.Node, 0, len(nodes))
+	next:
+		for _, n := range nodes {
+			md := n.Metadata()
+			for k, v := range labels {
+				if value, ok := md[k]; !ok || value != v {
+					continue next
+				}
+			}
+			filtered = append(filtered, n)
+		}
+		return filtered
+	}
+}
+
+// LabelSelector 按标签表达式过滤节点元数据，语法与 kubernetes label selector 一致，
+// 例如 "env=prod,tier!=cache,gpu,!legacy,zone in (a,b),track notin (canary)"，表达式不合法时 panic
+func LabelSelector(expr string) selector.NodeFilter {
+	labels, err := ParseLabels(expr)
+	if err != nil {
+		panic(err)
+	}
+	return func(_ context.Context, nodes []selector.Node) []selector.Node {
+		filtered := make([]selector.Node, 0, len(nodes))
+		for _, n := range nodes {
+			if labels.Match(n.Metadata()) {
+				filtered = append(filtered, n)
+			}
+		}
+		return filtered
+	}
+}
+
+// Labels 解析后的标签表达式，所有条件都满足才匹配
+type Labels struct {
+	requirements []requirement
+}
+
+type requirement struct {
+	key    string
+	op     string
+	values []string
+}
+
+// ParseLabels 解析标签表达式
+func ParseLabels(expr string) (*Labels, error) {
+	labels := &Labels{}
+	for _, term := range splitTerms(expr) {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		r, err := parseRequirement(term)
+		if err != nil {
+			return nil, fmt.Errorf("selector: invalid label selector %q: %w", expr, err)
+		}
+		labels.requirements = append(labels.requirements, r)
+	}
+	return labels, nil
+}
+
+// Match 元数据是否满足全部条件
+func (l *Labels) Match(md map[string]string) bool {
+	for _, r := range l.requirements {
+		if !r.match(md) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r requirement) match(md map[string]string) bool {
+	value, ok := md[r.key]
+	switch r.op {
+	case "exists":
+		return ok
+	case "!exists":
+		return !ok
+	case "=":
+		return ok && value == r.values[0]
+	case "!=":
+		return !ok || value != r.values[0]
+	case "in":
+		return ok && contains(r.values, value)
+	default:
+		return !ok || !contains(r.values, value)
+	}
+}
+
+// splitTerms 按括号外的逗号分隔表达式
+func splitTerms(expr string) []string {
+	var (
+		terms []string
+		depth int
+		start int
+	)
+	for i, c := range expr {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				terms = append(terms, expr[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(terms, expr[start:])
+}
+
+func parseRequirement(term string) (requirement, error) {
+	if fields := strings.Fields(term); len(fields) >= 2 && (fields[1] == "in" || fields[1] == "notin") {
+		set := strings.TrimSpace(strings.Join(fields[2:], " "))
+		if !strings.HasPrefix(set, "(") || !strings.HasSuffix(set, ")") {
+			return requirement{}, fmt.Errorf("values of %q must be in parentheses", term)
+		}
+		var values []string
+		for _, v := range strings.Split(set[1:len(set)-1], ",") {
+			values = append(values, strings.TrimSpace(v))
+		}
+		return requirement{key: fields[0], op: fields[1], values: values}, nil
+	}
+	for _, op := range []string{"!=", "==", "="} {
+		if i := strings.Index(term, op); i >= 0 {
+			key, value := strings.TrimSpace(term[:i]), strings.TrimSpace(term[i+len(op):])
+			if key == "" {
+				return requirement{}, fmt.Errorf("empty key in %q", term)
+			}
+			if op == "==" {
+				op = "="
+			}
+			return requirement{key: key, op: op, values: []string{value}}, nil
+		}
+	}
+	op := "exists"
+	if strings.HasPrefix(term, "!") {
+		op, term = "!exists", strings.TrimSpace(term[1:])
+	}
+	if term == "" || strings.ContainsAny(term, " ()") {
+		return requirement{}, fmt.Errorf("invalid key %q", term)
+	}
+	return requirement{key: term, op: op}, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
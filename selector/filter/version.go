@@ -0,0 +1,263 @@
+// Package filter
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package filter
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-fox/fox/selector"
+)
+
+// Version 按语义化版本范围过滤节点，支持 >=、<=、>、<、=、!=、^、~、通配符 1.x 和 ||，
+// 例如 ">=1.2.0 <2.0.0 || ^3.1"，版本号无法解析的节点会被过滤，constraint 不合法时 panic
+func Version(constraint string) selector.NodeFilter {
+	c, err := ParseConstraint(constraint)
+	if err != nil {
+		panic(err)
+	}
+	return func(_ context.Context, nodes []selector.Node) []selector.Node {
+		filtered := make([]selector.Node, 0, len(nodes))
+		for _, n := range nodes {
+			if c.Check(n.Version()) {
+				filtered = append(filtered, n)
+			}
+		}
+		return filtered
+	}
+}
+
+// Constraint 语义化版本范围，多个 || 分隔的范围满足任意一个即可
+type Constraint struct {
+	groups [][]comparator
+}
+
+type comparator struct {
+	op string
+	v  semver
+}
+
+// ParseConstraint 解析语义化版本范围
+func ParseConstraint(constraint string) (*Constraint, error) {
+	c := &Constraint{}
+	for _, group := range strings.Split(constraint, "||") {
+		var comparators []comparator
+		tokens := strings.Fields(group)
+		for i := 0; i < len(tokens); i++ {
+			token := tokens[i]
+			// 兼容运算符和版本号之间有空格的写法，例如 ">= 1.2.0"
+			if strings.Trim(token, "<>=!^~") == "" && i+1 < len(tokens) {
+				i++
+				token += tokens[i]
+			}
+			parsed, err := parseComparator(token)
+			if err != nil {
+				return nil, fmt.Errorf("selector: invalid version constraint %q: %w", constraint, err)
+			}
+			comparators = append(comparators, parsed...)
+		}
+		c.groups = append(c.groups, comparators)
+	}
+	return c, nil
+}
+
+// Check 版本是否满足范围
+func (c *Constraint) Check(version string) bool {
+	v, err := parseSemver(version)
+	if err != nil {
+		return false
+	}
+	for _, group := range c.groups {
+		ok := true
+		for _, cmp := range group {
+			if !cmp.check(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (c comparator) check(v semver) bool {
+	r := v.compare(c.v)
+	switch c.op {
+	case ">":
+		return r > 0
+	case ">=":
+		return r >= 0
+	case "<":
+		return r < 0
+	case "<=":
+		return r <= 0
+	case "!=":
+		return r != 0
+	default:
+		return r == 0
+	}
+}
+
+// parseComparator 解析单个比较条件，^、~ 和通配符会展开为上下界两个条件
+func parseComparator(token string) ([]comparator, error) {
+	raw := strings.TrimLeft(token, "<>=!^~")
+	op := token[:len(token)-len(raw)]
+	v, parts, err := parsePartial(raw)
+	if err != nil {
+		return nil, err
+	}
+	lower := comparator{op: ">=", v: v}
+	switch op {
+	case "^":
+		upper := semver{major: v.major + 1}
+		if v.major == 0 && parts > 1 {
+			upper = semver{minor: v.minor + 1}
+			if v.minor == 0 && parts > 2 {
+				upper = semver{patch: v.patch + 1}
+			}
+		}
+		return []comparator{lower, {op: "<", v: upper}}, nil
+	case "~":
+		if parts == 1 {
+			return []comparator{lower, {op: "<", v: semver{major: v.major + 1}}}, nil
+		}
+		return []comparator{lower, {op: "<", v: semver{major: v.major, minor: v.minor + 1}}}, nil
+	case "", "=":
+		switch parts {
+		case 0:
+			return nil, nil
+		case 1:
+			return []comparator{lower, {op: "<", v: semver{major: v.major + 1}}}, nil
+		case 2:
+			return []comparator{lower, {op: "<", v: semver{major: v.major, minor: v.minor + 1}}}, nil
+		}
+		return []comparator{{op: "=", v: v}}, nil
+	case ">", ">=", "<", "<=", "!=":
+		return []comparator{{op: op, v: v}}, nil
+	}
+	return nil, fmt.Errorf("unknown operator %q", op)
+}
+
+// semver 语义化版本
+type semver struct {
+	major, minor, patch int64
+	pre                 string
+}
+
+// parseSemver 解析版本号，缺少的部分按 0 处理
+func parseSemver(version string) (semver, error) {
+	v, _, err := parsePartial(version)
+	return v, err
+}
+
+// parsePartial 解析可能不完整或带通配符的版本号，返回确定的部分数量
+func parsePartial(version string) (semver, int, error) {
+	var v semver
+	version = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(version), "v"), "V")
+	if i := strings.IndexByte(version, '+'); i >= 0 {
+		version = version[:i]
+	}
+	if i := strings.IndexByte(version, '-'); i >= 0 {
+		version, v.pre = version[:i], version[i+1:]
+	}
+	if version == "" || version == "*" || version == "x" || version == "X" {
+		return v, 0, nil
+	}
+	fields := strings.Split(version, ".")
+	if len(fields) > 3 {
+		return v, 0, fmt.Errorf("invalid version %q", version)
+	}
+	numbers := []*int64{&v.major, &v.minor, &v.patch}
+	parts := 0
+	for i, field := range fields {
+		if field == "*" || field == "x" || field == "X" {
+			break
+		}
+		n, err := strconv.ParseInt(field, 10, 64)
+		if err != nil || n < 0 {
+			return v, 0, fmt.Errorf("invalid version %q", version)
+		}
+		*numbers[i] = n
+		parts++
+	}
+	return v, parts, nil
+}
+
+// compare 比较版本，预发布版本小于对应的正式版本
+func (v semver) compare(o semver) int {
+	for _, d := range []int64{v.major - o.major, v.minor - o.minor, v.patch - o.patch} {
+		if d != 0 {
+			if d > 0 {
+				return 1
+			}
+			return -1
+		}
+	}
+	switch {
+	case v.pre == o.pre:
+		return 0
+	case v.pre == "":
+		return 1
+	case o.pre == "":
+		return -1
+	}
+	return comparePre(v.pre, o.pre)
+}
+
+// comparePre 按点分隔逐段比较预发布标识，数字按数值比较且小于字母
+func comparePre(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.ParseInt(as[i], 10, 64)
+		bn, bErr := strconv.ParseInt(bs[i], 10, 64)
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				if an > bn {
+					return 1
+				}
+				return -1
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	switch {
+	case len(as) > len(bs):
+		return 1
+	case len(as) < len(bs):
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,131 @@
+// Package filter
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package filter
+
+import (
+	"context"
+
+	"github.com/go-fox/fox/selector"
+)
+
+const (
+	// RegionKey 节点元数据中的地域
+	RegionKey = "region"
+	// ZoneKey 节点元数据中的分区
+	ZoneKey = "zone"
+)
+
+// ZoneOption zone filter option
+type ZoneOption func(o *zoneOptions)
+
+type zoneOptions struct {
+	minHealthy int
+	minRatio   float64
+	healthy    func(n selector.Node) bool
+}
+
+// WithMinHealthy 同分区的健康节点少于 n 个时回退，默认为 1
+func WithMinHealthy(n int) ZoneOption {
+	return func(o *zoneOptions) {
+		o.minHealthy = n
+	}
+}
+
+// WithMinHealthyRatio 同分区的健康节点占全部健康节点的比例低于 ratio 时回退
+func WithMinHealthyRatio(ratio float64) ZoneOption {
+	return func(o *zoneOptions) {
+		o.minRatio = ratio
+	}
+}
+
+// WithHealthCheck 判断节点是否健康，默认权重不大于 0 的节点视为不健康
+func WithHealthCheck(healthy func(n selector.Node) bool) ZoneOption {
+	return func(o *zoneOptions) {
+		o.healthy = healthy
+	}
+}
+
+// Zone 同分区优先，同分区的健康节点不足时回退到同地域，仍然不足时返回全部节点；
+// 节点的地域和分区来自元数据的 region 和 zone，默认权重不大于 0 的节点视为不健康，
+// 开启被动健康检查（base.SetOutlierConfig）时被驱逐的节点在过滤前已被移除，不计入健康节点
+func Zone(region, zone string, opts ...ZoneOption) selector.NodeFilter {
+	o := zoneOptions{minHealthy: 1, healthy: healthy}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(_ context.Context, nodes []selector.Node) []selector.Node {
+		if region == "" && zone == "" {
+			return nodes
+		}
+		total := 0
+		for _, n := range nodes {
+			if o.healthy(n) {
+				total++
+			}
+		}
+		if zone != "" {
+			if matched, n := o.matchZone(nodes, region, zone); o.enough(n, total) {
+				return matched
+			}
+		}
+		if region != "" {
+			if matched, n := o.matchZone(nodes, region, ""); o.enough(n, total) {
+				return matched
+			}
+		}
+		return nodes
+	}
+}
+
+// matchZone 返回地域和分区匹配的节点，以及其中健康节点的数量，参数为空时不比较
+func (o zoneOptions) matchZone(nodes []selector.Node, region, zone string) ([]selector.Node, int) {
+	matched := make([]selector.Node, 0, len(nodes))
+	count := 0
+	for _, n := range nodes {
+		md := n.Metadata()
+		if (region != "" && md[RegionKey] != region) || (zone != "" && md[ZoneKey] != zone) {
+			continue
+		}
+		matched = append(matched, n)
+		if o.healthy(n) {
+			count++
+		}
+	}
+	return matched, count
+}
+
+func (o zoneOptions) enough(n, total int) bool {
+	if n == 0 || n < o.minHealthy {
+		return false
+	}
+	return o.minRatio <= 0 || float64(n)/float64(total) >= o.minRatio
+}
+
+// healthy 负载均衡运行时计算的权重不大于 0 时视为不健康，例如 ewma 节点持续失败
+func healthy(n selector.Node) bool {
+	if wn, ok := n.(interface{ Weight() float64 }); ok {
+		return wn.Weight() > 0
+	}
+	return true
+}
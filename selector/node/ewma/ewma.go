@@ -0,0 +1,138 @@
+// Package ewma
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package ewma
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-fox/fox/selector"
+)
+
+const (
+	defaultWeight = 100
+	// tau 指数衰减的时间常数，越大历史数据的影响越久
+	tau = int64(600 * time.Millisecond)
+	// penalty 还没有统计数据时使用的延迟，避免冷启动的节点被大量请求打满
+	penalty = int64(10 * time.Second)
+	// maxSuccess 成功率的满分
+	maxSuccess = 1000
+)
+
+var (
+	_ selector.WeightedNode        = (*Node)(nil)
+	_ selector.WeightedNodeBuilder = (*Builder)(nil)
+)
+
+// Node impl selector.WeightedNode, weight is calculated by ewma latency, in-flight requests and success rate
+type Node struct {
+	selector.Node
+	// lag ewma latency in nanoseconds
+	lag int64
+	// success ewma success rate, [0, maxSuccess]
+	success int64
+	// inflight requests in flight
+	inflight int64
+	// stamp last done timestamp
+	stamp int64
+	// lastPick last pick timestamp
+	lastPick int64
+	// errHandler reports whether the error counts as a failure
+	errHandler func(err error) bool
+}
+
+// Raw is original node
+func (n *Node) Raw() selector.Node {
+	return n.Node
+}
+
+// Weight is node effective weight, higher is better
+func (n *Node) Weight() float64 {
+	weight := float64(defaultWeight)
+	if n.InitialWeight() != nil {
+		weight = float64(*n.InitialWeight())
+	}
+	lag := atomic.LoadInt64(&n.lag)
+	if lag <= 0 {
+		lag = penalty
+	}
+	load := float64(lag) * float64(atomic.LoadInt64(&n.inflight)+1)
+	return weight * float64(atomic.LoadInt64(&n.success)) / load
+}
+
+// Pick is pick a node, the done func updates latency and success rate
+func (n *Node) Pick() selector.DoneFunc {
+	start := time.Now().UnixNano()
+	atomic.StoreInt64(&n.lastPick, start)
+	atomic.AddInt64(&n.inflight, 1)
+	return func(ctx context.Context, di selector.DoneInfo) {
+		atomic.AddInt64(&n.inflight, -1)
+		now := time.Now().UnixNano()
+		rtt := max(now-start, 0)
+		td := max(now-atomic.SwapInt64(&n.stamp, now), 0)
+		// 距离上次统计越久，历史数据的权重越低
+		w := math.Exp(-float64(td) / float64(tau))
+
+		lag := atomic.LoadInt64(&n.lag)
+		atomic.StoreInt64(&n.lag, int64(float64(lag)*w+float64(rtt)*(1-w)))
+
+		var success int64 = maxSuccess
+		if n.errHandler(di.Err) {
+			success = 0
+		}
+		old := atomic.LoadInt64(&n.success)
+		atomic.StoreInt64(&n.success, int64(float64(old)*w+float64(success)*(1-w)))
+	}
+}
+
+// PickElapsed select node time
+func (n *Node) PickElapsed() time.Duration {
+	return time.Duration(time.Now().UnixNano() - atomic.LoadInt64(&n.lastPick))
+}
+
+// Builder impl selector.WeightedNodeBuilder
+type Builder struct {
+	// ErrHandler reports whether the error counts as a failure, default is DefaultErrHandler
+	ErrHandler func(err error) bool
+}
+
+// Build is create weight node
+func (b *Builder) Build(node selector.Node) selector.WeightedNode {
+	errHandler := b.ErrHandler
+	if errHandler == nil {
+		errHandler = DefaultErrHandler
+	}
+	return &Node{
+		Node:       node,
+		success:    maxSuccess,
+		errHandler: errHandler,
+	}
+}
+
+// DefaultErrHandler 服务端错误、超时和不可用视为失败，客户端错误和主动取消不影响成功率
+func DefaultErrHandler(err error) bool {
+	return selector.DefaultErrHandler(err)
+}
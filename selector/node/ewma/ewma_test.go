@@ -0,0 +1,31 @@
+package ewma
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/go-fox/fox/errors"
+)
+
+func TestDefaultErrHandler(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		want bool
+	}{
+		"nil":         {nil, false},
+		"canceled":    {context.Canceled, false},
+		"deadline":    {context.DeadlineExceeded, true},
+		"not found":   {errors.NotFound("NOT_FOUND", ""), false},
+		"unavailable": {errors.ServiceUnavailable("UNAVAILABLE", ""), true},
+		"grpc":        {status.Error(codes.Unavailable, ""), true},
+		"grpc client": {status.Error(codes.InvalidArgument, ""), false},
+	}
+	for name, tt := range tests {
+		if got := DefaultErrHandler(tt.err); got != tt.want {
+			t.Errorf("%s: DefaultErrHandler() = %v, want %v", name, got, tt.want)
+		}
+	}
+}
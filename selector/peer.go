@@ -34,6 +34,9 @@ type peerKey struct{}
 type Peer struct {
 	// node is the peer node.
 	Node Node
+	// Selected is called with the node once it is selected, before the request is sent to it,
+	// it runs in the goroutine of the request
+	Selected func(node Node)
 }
 
 // NewPeerContext creates a new context with peer information attached.
@@ -27,6 +27,7 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"reflect"
 	"time"
 
 	"google.golang.org/grpc"
@@ -34,6 +35,7 @@ import (
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	grpcmd "google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/go-fox/fox/middleware"
 	"github.com/go-fox/fox/selector"
@@ -44,14 +46,21 @@ import (
 func init() {
 	all := selector.GetAll()
 	all.Iterator(func(key string, builder selector.Builder) bool {
-		old := balancer.Get(builder.Name())
-		if old == nil {
+		if balancer.Get(builder.Name()) == nil {
 			balancer.Register(newBalancerBuilder(builder))
 		}
 		return true
 	})
 }
 
+// RegisterBalancer 注册选择器并作为 grpc balancer 使用，grpc 包只在初始化时注册已有的选择器，
+// 之后注册的选择器（例如通过 ringhash.WithName 自定义的选择器）需要调用该方法，
+// 与 grpc 的 balancer.Register 一样只能在 init 中调用
+func RegisterBalancer(builder selector.Builder) {
+	selector.Register(builder)
+	balancer.Register(newBalancerBuilder(builder))
+}
+
 // Client is grpc client
 type Client struct {
 	config *ClientConfig
@@ -147,6 +156,7 @@ func (c *Client) unaryClientInterceptor(ms []middleware.Middleware, timeout time
 			defer cancel()
 		}
 		h := func(ctx context.Context, req interface{}) (interface{}, error) {
+			out := reply
 			if tr, ok := transport.FromClientContext(ctx); ok {
 				header := tr.RequestHeader()
 				keys := header.Keys()
@@ -155,19 +165,40 @@ func (c *Client) unaryClientInterceptor(ms []middleware.Middleware, timeout time
 					keyvals = append(keyvals, k, header.Get(k))
 				}
 				ctx = grpcmd.AppendToOutgoingContext(ctx, keyvals...)
+				// 对冲请求并发调用 handler，解码到独立的 reply，由胜出的结果写回
+				if gtr, ok := tr.(*Transport); ok && gtr.forked {
+					out = reflect.New(reflect.TypeOf(reply).Elem()).Interface()
+				}
 			}
-			return reply, invoker(ctx, method, req, reply, cc, opts...)
+			return out, invoker(ctx, method, req, out, cc, opts...)
 		}
 		if len(ms) > 0 {
 			h = middleware.Chain(ms...)(h)
 		}
 		var p selector.Peer
 		ctx = selector.NewPeerContext(ctx, &p)
-		_, err := h(ctx, req)
+		out, err := h(ctx, req)
+		if err == nil && out != nil && out != reply {
+			copyReply(reply, out)
+		}
 		return err
 	}
 }
 
+// copyReply 把对冲请求解码出的 reply 写回调用方的 reply，类型不同时忽略
+func copyReply(dst, src interface{}) {
+	dv, sv := reflect.ValueOf(dst), reflect.ValueOf(src)
+	if dv.Kind() != reflect.Pointer || dv.Type() != sv.Type() {
+		return
+	}
+	if dm, ok := dst.(proto.Message); ok {
+		proto.Reset(dm)
+		proto.Merge(dm, src.(proto.Message))
+		return
+	}
+	dv.Elem().Set(sv.Elem())
+}
+
 func (c *Client) streamClientInterceptor(filters []selector.NodeFilter) grpc.StreamClientInterceptor {
 	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) { // nolint
 		ctx = transport.NewClientContext(ctx, &Transport{
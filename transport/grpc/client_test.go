@@ -0,0 +1,135 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/go-fox/fox/middleware"
+	"github.com/go-fox/fox/middleware/retry"
+	"github.com/go-fox/fox/registry"
+	"github.com/go-fox/fox/registry/memory"
+	"github.com/go-fox/fox/selector/balancer/random"
+	"github.com/go-fox/fox/transport"
+)
+
+// hedgeHealth 多个节点共享的健康检查服务，同一时间只有一个请求时视为首次请求，在 slow 后才返回
+type hedgeHealth struct {
+	mu       sync.Mutex
+	inFlight int
+	calls    []string
+	slow     time.Duration
+}
+
+type hedgeNode struct {
+	grpc_health_v1.UnimplementedHealthServer
+	name   string
+	shared *hedgeHealth
+}
+
+func (n *hedgeNode) Check(ctx context.Context, _ *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	h := n.shared
+	h.mu.Lock()
+	first, slow := h.inFlight == 0, h.slow
+	h.inFlight++
+	h.calls = append(h.calls, n.name)
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		h.inFlight--
+		h.mu.Unlock()
+	}()
+	if !first {
+		return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(slow):
+		return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_NOT_SERVING}, nil
+	}
+}
+
+func TestClientHedging(t *testing.T) {
+	shared := &hedgeHealth{slow: time.Second}
+	reg := memory.New()
+	for _, name := range []string{"a", "b"} {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv := grpc.NewServer()
+		grpc_health_v1.RegisterHealthServer(srv, &hedgeNode{name: name, shared: shared})
+		go func() { _ = srv.Serve(lis) }()
+		defer srv.Stop()
+		err = reg.Register(context.Background(), &registry.ServiceInstance{
+			ID:        name,
+			Name:      "hedge",
+			Endpoints: []string{"grpc://" + lis.Addr().String()},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 对冲之后的中间件修改请求头，每次请求使用独立的 transport
+	header := func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if tr, ok := transport.FromClientContext(ctx); ok {
+				tr.RequestHeader().Set("x-hedge", "1")
+			}
+			return handler(ctx, req)
+		}
+	}
+	c := DefaultClientConfig().With(
+		WithEndpoint("discovery:///hedge"),
+		WithDiscovery(reg),
+		WithHealthCheck(false),
+		WithMiddleware(retry.Client(retry.WithHedging(50*time.Millisecond, grpc_health_v1.Health_Check_FullMethodName)), header),
+	)
+	c.BalancerName = random.Name
+	client := c.Build()
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// 等待两个节点都可用
+	hc := grpc_health_v1.NewHealthClient(client)
+	shared.slow = 0
+	seen := map[string]bool{}
+	for len(seen) < 2 {
+		if _, err := hc.Check(ctx, &grpc_health_v1.HealthCheckRequest{}); err != nil {
+			t.Fatal(err)
+		}
+		shared.mu.Lock()
+		for _, name := range shared.calls {
+			seen[name] = true
+		}
+		shared.calls = nil
+		shared.mu.Unlock()
+	}
+	shared.slow = time.Second
+
+	start := time.Now()
+	reply, err := hc.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("elapsed %v, want the hedged reply", elapsed)
+	}
+	if reply.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("status = %v, want the hedged reply", reply.GetStatus())
+	}
+	shared.mu.Lock()
+	calls := append([]string(nil), shared.calls...)
+	shared.mu.Unlock()
+	if len(calls) != 2 || calls[0] == calls[1] {
+		t.Fatalf("calls = %v, want one hedge on the other node", calls)
+	}
+}
@@ -36,7 +36,11 @@ import (
 	"github.com/go-fox/fox/middleware"
 	"github.com/go-fox/fox/registry"
 	"github.com/go-fox/fox/selector"
-	"github.com/go-fox/fox/selector/balancer/wrr"
+	"github.com/go-fox/fox/selector/balancer/p2c"
+	// grpc 只在初始化时注册已有的选择器，导入内置选择器保证它们先于 grpc 包注册，
+	// 其他选择器通过 RegisterBalancer 注册
+	_ "github.com/go-fox/fox/selector/balancer/ringhash"
+	_ "github.com/go-fox/fox/selector/balancer/wrr"
 )
 
 // ServerConfig create server config
@@ -182,7 +186,7 @@ func Options(opts ...grpc.ServerOption) ServerOption {
 type ClientConfig struct {
 	Endpoint           string        `json:"endpoint"`
 	Timeout            time.Duration `json:"timeout"`
-	BalancerName       string        `json:"balancer_name"`
+	BalancerName       string        `json:"balancer_name"` // 选择器名称，需是内置选择器或通过 RegisterBalancer 注册的选择器
 	Insecure           bool          `json:"insecure"`
 	Debug              bool          `json:"debug"`
 	CertFile           string        `json:"cert_file"`
@@ -201,7 +205,7 @@ type ClientConfig struct {
 func DefaultClientConfig() *ClientConfig {
 	return &ClientConfig{
 		Timeout:           2000 * time.Millisecond,
-		BalancerName:      wrr.Name,
+		BalancerName:      p2c.Name,
 		Insecure:          true,
 		healthCheckConfig: `,"healthCheckConfig":{"serviceName":""}`,
 	}
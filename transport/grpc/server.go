@@ -37,6 +37,7 @@ import (
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
 
+	foxHealth "github.com/go-fox/fox/health"
 	"github.com/go-fox/fox/internal/matcher"
 	"github.com/go-fox/fox/middleware"
 	"github.com/go-fox/fox/transport"
@@ -141,17 +142,39 @@ func (s *Server) Start(ctx context.Context) error {
 		return err
 	}
 	s.baseCtx = ctx
-	s.health.Resume()
+	if h, ok := foxHealth.FromContext(ctx); ok {
+		// 与应用共享健康状态
+		h.Watch(func(status foxHealth.Status) {
+			if status == foxHealth.StatusServing {
+				s.health.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+			} else {
+				s.health.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+			}
+		})
+	} else {
+		s.health.Resume()
+	}
 	s.config.log.Info(fmt.Sprintf("[gRPC] server listening on: %s", s.config.lis.Addr().String()))
 	return s.Serve(s.config.lis)
 }
 
-// Stop 停止
+// Stop 停止，等待正在处理的请求完成，超过 ctx 期限后强制关闭
 func (s *Server) Stop(ctx context.Context) error {
-	s.GracefulStop()
 	s.health.Shutdown()
 	s.config.log.Info("[gRPC] server stopping")
-	return nil
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.config.log.Warn("[gRPC] server couldn't stop gracefully in time, doing force stop")
+		s.Server.Stop()
+		return ctx.Err()
+	}
 }
 
 // listenAndEndpoint is get server start address
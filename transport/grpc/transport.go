@@ -44,6 +44,7 @@ type Transport struct {
 	replyHeader headerCarrier
 	nodeFilters []selector.NodeFilter
 	remoteAddr  net.Addr
+	forked      bool // 对冲请求复制出的 transport，使用独立的 reply
 }
 
 // RemoteAddr 获取远程连接地址
@@ -81,6 +82,19 @@ func (tr *Transport) NodeFilters() []selector.NodeFilter {
 	return tr.nodeFilters
 }
 
+// Fork 复制一份 transport 供对冲请求并发使用，请求头相互独立
+func (tr *Transport) Fork() transport.Transporter {
+	return &Transport{
+		endpoint:    tr.endpoint,
+		operation:   tr.operation,
+		reqHeader:   headerCarrier(metadata.MD(tr.reqHeader).Copy()),
+		replyHeader: headerCarrier(metadata.MD(tr.replyHeader).Copy()),
+		nodeFilters: tr.nodeFilters,
+		remoteAddr:  tr.remoteAddr,
+		forked:      true,
+	}
+}
+
 type headerCarrier metadata.MD
 
 // Get returns the value associated with the passed key.
@@ -131,7 +131,7 @@ func (c *Client) Invoke(ctx context.Context, method, path string, args interface
 	req.SetRequestURI(url)
 	req.Header.SetMethod(method)
 	req.SetBody(body)
-	transport.NewClientContext(ctx, &Transport{
+	ctx = transport.NewClientContext(ctx, &Transport{
 		endpoint:     c.config.Endpoint,
 		request:      req,
 		response:     resp,
@@ -159,7 +159,7 @@ func (c *Client) invoke(ctx context.Context, req *Request, resp *Response, args
 		return reply, nil
 	}
 	var p selector.Peer
-	ctx = transport.NewPeerClient(ctx, &p)
+	ctx = selector.NewPeerContext(ctx, &p)
 	if len(c.config.middleware) > 0 {
 		h = middleware.Chain(c.config.middleware...)(h)
 	}
@@ -33,7 +33,8 @@ import (
 	"github.com/go-fox/fox/middleware"
 	"github.com/go-fox/fox/registry"
 	"github.com/go-fox/fox/selector"
-	"github.com/go-fox/fox/selector/balancer/wrr"
+	"github.com/go-fox/fox/selector/balancer/p2c"
+	_ "github.com/go-fox/fox/selector/balancer/wrr" // 注册 wrr，可以通过名称选择
 )
 
 // ClientConfig client config
@@ -66,7 +67,7 @@ func DefaultClientConfig() *ClientConfig {
 		Debug:          false,
 		Timeout:        time.Second * 2,
 		Block:          true,
-		BalancerName:   wrr.Name,
+		BalancerName:   p2c.Name,
 		encodeRequest:  DefaultRequestEncoder,
 		decodeResponse: DefaultResponseDecoder,
 		errorDecoder:   DefaultErrorDecoder,
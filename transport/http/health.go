@@ -0,0 +1,78 @@
+// Package http
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package http
+
+import (
+	"github.com/go-fox/fox/health"
+)
+
+const (
+	// HealthzPath liveness probe path
+	HealthzPath = "/healthz"
+	// ReadyzPath readiness probe path
+	ReadyzPath = "/readyz"
+)
+
+// healthReply health probe reply, the check errors are not exposed to unauthenticated callers,
+// use Health.Results to get them
+type healthReply struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// registerHealth register health probe routes
+func (s *Server) registerHealth() {
+	s.router.Get(HealthzPath, s.healthz)
+	s.router.Get(ReadyzPath, s.readyz)
+}
+
+// healthz liveness probe handler
+func (s *Server) healthz(ctx *Context) error {
+	if !s.health.Load().Live() {
+		return ctx.JSON(StatusServiceUnavailable, &healthReply{Status: health.StatusNotServing.String()})
+	}
+	return ctx.JSON(StatusOK, &healthReply{Status: health.StatusServing.String()})
+}
+
+// readyz readiness probe handler
+func (s *Server) readyz(ctx *Context) error {
+	h := s.health.Load()
+	reply := &healthReply{
+		Status: h.Status().String(),
+	}
+	for name, err := range h.Results() {
+		if reply.Checks == nil {
+			reply.Checks = make(map[string]string)
+		}
+		if err != nil {
+			reply.Checks[name] = "failed"
+		} else {
+			reply.Checks[name] = "ok"
+		}
+	}
+	if !h.Ready() {
+		return ctx.JSON(StatusServiceUnavailable, reply)
+	}
+	return ctx.JSON(StatusOK, reply)
+}
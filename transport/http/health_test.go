@@ -0,0 +1,112 @@
+// Package http
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-fox/fox/health"
+)
+
+func getHealth(t *testing.T, url string) (int, *healthReply) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply := &healthReply{}
+	if err = json.Unmarshal(body, reply); err != nil {
+		t.Fatalf("unmarshal %s: %v", body, err)
+	}
+	return resp.StatusCode, reply
+}
+
+func TestHealthProbes(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var failing atomic.Bool
+	h := health.New(health.WithChecker("redis", health.CheckerFunc(func(ctx context.Context) error {
+		if failing.Load() {
+			return errors.New("dial tcp 10.0.0.1:6379: connection refused")
+		}
+		return nil
+	})))
+	srv := NewServer(Listener(lis))
+	go func() {
+		_ = srv.Start(health.NewContext(context.Background(), h))
+	}()
+	defer srv.Stop(context.Background())
+	base := "http://" + lis.Addr().String()
+
+	// 等待服务启动
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err = http.Get(base + HealthzPath); err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if code, reply := getHealth(t, base+HealthzPath); code != http.StatusOK || reply.Status != "SERVING" {
+		t.Fatalf("healthz = %d %+v", code, reply)
+	}
+	if code, reply := getHealth(t, base+ReadyzPath); code != http.StatusServiceUnavailable || reply.Status != "NOT_SERVING" {
+		t.Fatalf("readyz before resume = %d %+v", code, reply)
+	}
+
+	h.Resume()
+	if code, reply := getHealth(t, base+ReadyzPath); code != http.StatusOK || reply.Checks["redis"] != "ok" {
+		t.Fatalf("readyz = %d %+v", code, reply)
+	}
+
+	// 检查失败时不返回错误详情
+	failing.Store(true)
+	h.Check(context.Background())
+	code, reply := getHealth(t, base+ReadyzPath)
+	if code != http.StatusServiceUnavailable || reply.Checks["redis"] != "failed" {
+		t.Fatalf("readyz with failing check = %d %+v", code, reply)
+	}
+
+	h.Shutdown()
+	if code, _ := getHealth(t, base+ReadyzPath); code != http.StatusServiceUnavailable {
+		t.Fatalf("readyz after shutdown = %d", code)
+	}
+	if code, _ := getHealth(t, base+HealthzPath); code != http.StatusOK {
+		t.Fatalf("healthz after shutdown = %d", code)
+	}
+}
@@ -32,11 +32,13 @@ import (
 	"net/url"
 	"sync"
 
+	"github.com/go-fox/sugar/container/satomic"
 	"github.com/go-fox/sugar/util/shost"
 	"github.com/go-fox/sugar/util/surl"
 	"github.com/valyala/fasthttp"
 
 	"github.com/go-fox/fox/errors"
+	"github.com/go-fox/fox/health"
 	"github.com/go-fox/fox/middleware"
 	"github.com/go-fox/fox/transport"
 )
@@ -59,6 +61,9 @@ type Server struct {
 	fsInstanceMux sync.Mutex
 	initOnce      sync.Once
 	config        *ServerConfig
+	health        *satomic.Value[*health.Health]
+	connMu        sync.Mutex
+	conns         map[net.Conn]struct{} // 打开的连接，停止超过期限后强制关闭
 	*router
 }
 
@@ -86,7 +91,9 @@ func NewWithConfig(cs ...*ServerConfig) *Server {
 		baseCtx:       context.Background(),
 		config:        c,
 		fsInstanceMux: sync.Mutex{},
+		health:        satomic.New[*health.Health](),
 	}
+	srv.health.Store(health.New())
 	if c.KeyFile != "" && c.CertFile != "" && c.tlsConf == nil {
 		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
 		if err != nil {
@@ -122,6 +129,7 @@ func (s *Server) init() {
 			WriteBufferSize:               conf.WriteBufferSize,
 			ReduceMemoryUsage:             conf.ReduceMemoryUsage,
 			StreamRequestBody:             conf.StreamRequestBody,
+			ConnState:                     s.trackConn,
 		}
 		// 配置http中间件
 		var mws []any
@@ -129,6 +137,10 @@ func (s *Server) init() {
 			mws = append(mws, httpMiddleware)
 		}
 		s.Use(mws...)
+		// 健康检查
+		if !conf.CustomHealth {
+			s.registerHealth()
+		}
 	})
 }
 
@@ -213,6 +225,11 @@ func (s *Server) Start(ctx context.Context) error {
 	if err := s.listenAndEndpoint(); err != nil {
 		return err
 	}
+	if h, ok := health.FromContext(ctx); ok {
+		s.health.Store(h)
+	} else {
+		s.health.Load().Resume()
+	}
 	s.config.logger.Info(fmt.Sprintf("[HTTP] server listening on: %s", s.config.listener.Addr().String()))
 	var err error
 	listener := s.config.listener
@@ -227,8 +244,41 @@ func (s *Server) Start(ctx context.Context) error {
 	return nil
 }
 
-// Stop is stop this server
+// Stop is stop this server, waits for the requests in flight and force closes the connections after ctx is done
 func (s *Server) Stop(ctx context.Context) error {
+	s.health.Load().Shutdown()
 	s.config.logger.Info("[HTTP] server stopping")
-	return s.fastSrv.ShutdownWithContext(ctx)
+	err := s.fastSrv.ShutdownWithContext(ctx)
+	if err != nil && ctx.Err() != nil {
+		s.closeConns()
+	}
+	return err
+}
+
+// trackConn 记录打开的连接，被劫持的连接由劫持方负责关闭
+func (s *Server) trackConn(conn net.Conn, state fasthttp.ConnState) {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	switch state {
+	case fasthttp.StateNew:
+		if s.conns == nil {
+			s.conns = make(map[net.Conn]struct{})
+		}
+		s.conns[conn] = struct{}{}
+	case fasthttp.StateClosed, fasthttp.StateHijacked:
+		delete(s.conns, conn)
+	}
+}
+
+// closeConns 强制关闭所有打开的连接
+func (s *Server) closeConns() {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	if len(s.conns) > 0 {
+		s.config.logger.Warn("[HTTP] force closed connections after stop deadline", "count", len(s.conns))
+	}
+	s.conns = nil
 }
@@ -0,0 +1,82 @@
+// Package http
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStopForceClose(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(Listener(lis))
+	entered := make(chan struct{})
+	srv.Get("/slow", func(ctx *Context) error {
+		close(entered)
+		time.Sleep(2 * time.Second)
+		return ctx.JSON(StatusOK, "ok")
+	})
+	go func() {
+		_ = srv.Start(context.Background())
+	}()
+
+	result := make(chan error, 1)
+	go func() {
+		resp, err := http.Get("http://" + lis.Addr().String() + "/slow")
+		if err == nil {
+			resp.Body.Close()
+		}
+		result <- err
+	}()
+	select {
+	case <-entered:
+	case <-time.After(time.Second):
+		t.Fatal("request not started")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err = srv.Stop(ctx); err == nil {
+		t.Fatal("stop should fail when the request is not drained")
+	}
+	// 超过期限后连接被强制关闭，客户端立即收到错误
+	select {
+	case err = <-result:
+		if err == nil {
+			t.Fatal("request should fail after force close")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("connection not closed after stop deadline")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("stop took %v", elapsed)
+	}
+}
@@ -47,6 +47,7 @@ type ServerConfig struct {
 	WriteBufferSize    int           `json:"write_buffer_size"`
 	ReduceMemoryUsage  bool          `json:"reduce_memory_usage"`
 	StreamRequestBody  bool          `json:"stream_request_body"`
+	CustomHealth       bool          `json:"custom_health"`
 	httpMiddlewares    []Handler     // http中间件
 	listener           net.Listener
 	tlsConf            *tls.Config
@@ -167,6 +168,13 @@ func StreamRequestBody(streamRequestBody bool) ServerOption {
 	}
 }
 
+// CustomHealth with custom health, the built-in /healthz and /readyz routes will not be registered
+func CustomHealth(customHealth bool) ServerOption {
+	return func(o *ServerConfig) {
+		o.CustomHealth = customHealth
+	}
+}
+
 // Listener with a server lis option
 func Listener(lis net.Listener) ServerOption {
 	return func(o *ServerConfig) {
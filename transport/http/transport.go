@@ -76,6 +76,16 @@ func (t *Transport) ReplyHeader() transport.Header {
 	return newHeaderCarrier(&t.response.Header)
 }
 
+// Cookie returns the request cookie value.
+func (t *Transport) Cookie(key string) string {
+	return string(t.request.Header.Cookie(key))
+}
+
+// Query returns the request query value.
+func (t *Transport) Query(key string) string {
+	return string(t.request.URI().QueryArgs().Peek(key))
+}
+
 type header interface {
 	Add(key string, value string)
 	Peek(key string) []byte
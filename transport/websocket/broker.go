@@ -0,0 +1,93 @@
+// Package websocket
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package websocket
+
+import (
+	"context"
+	"sync"
+)
+
+// BrokerMessage 跨节点推送的消息，目标按 SessionId、Key/Value、Room 的优先级选择
+type BrokerMessage struct {
+	SessionId string `json:"session_id,omitempty"` // 目标 session
+	Key       string `json:"key,omitempty"`        // 目标 session 的元数据 key，例如用户id
+	Value     string `json:"value,omitempty"`      // 目标 session 的元数据 value
+	Room      string `json:"room,omitempty"`       // 目标房间，为空时推送给所有 session
+	Data      []byte `json:"data"`                 // 已编码的 protocol.Reply
+}
+
+// BrokerHandler 处理订阅到的消息
+type BrokerHandler func(msg *BrokerMessage)
+
+// Broker 跨节点消息总线，集群中的每个节点都订阅同一个总线，推送时由持有连接的节点投递
+type Broker interface {
+	// Publish 发布消息到所有节点，包括当前节点
+	Publish(ctx context.Context, msg *BrokerMessage) error
+	// Subscribe 订阅消息，订阅在 ctx 结束时取消，方法本身不阻塞
+	Subscribe(ctx context.Context, handler BrokerHandler) error
+}
+
+var _ Broker = (*MemoryBroker)(nil)
+
+// MemoryBroker 进程内消息总线，适用于单进程多服务和测试
+type MemoryBroker struct {
+	mu       sync.RWMutex
+	handlers map[int]BrokerHandler
+	next     int
+}
+
+// NewMemoryBroker create a memory broker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{handlers: make(map[int]BrokerHandler)}
+}
+
+// Publish 同步投递给所有订阅者
+func (b *MemoryBroker) Publish(_ context.Context, msg *BrokerMessage) error {
+	b.mu.RLock()
+	handlers := make([]BrokerHandler, 0, len(b.handlers))
+	for _, h := range b.handlers {
+		handlers = append(handlers, h)
+	}
+	b.mu.RUnlock()
+	for _, h := range handlers {
+		h(msg)
+	}
+	return nil
+}
+
+// Subscribe 订阅消息
+func (b *MemoryBroker) Subscribe(ctx context.Context, handler BrokerHandler) error {
+	b.mu.Lock()
+	id := b.next
+	b.next++
+	b.handlers[id] = handler
+	b.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.handlers, id)
+		b.mu.Unlock()
+	}()
+	return nil
+}
@@ -0,0 +1,38 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBroker(t *testing.T) {
+	broker := NewMemoryBroker()
+	node1, endpoint1 := newTestServer(t, WithBroker(broker))
+	node2, endpoint2 := newTestServer(t, WithBroker(broker))
+	conn1, conn2 := dialTest(t, endpoint1), dialTest(t, endpoint2)
+	joinTest(t, node2, conn2)
+	// 等待节点1的连接注册完成
+	deadline := time.Now().Add(time.Second)
+	for node1.SessionCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// 节点1推送给只连接在节点2上的用户
+	if err := node1.SendTo("uid", "1001", "notice", []byte("hi")); err != nil {
+		t.Fatal(err)
+	}
+	if reply := readTest(t, conn2); reply.Operation != "notice" || string(reply.Data) != "hi" {
+		t.Fatalf("unexpected reply: %v", reply)
+	}
+
+	// 节点1广播给所有节点的所有 session
+	if err := node1.Broadcast("", "all", nil); err != nil {
+		t.Fatal(err)
+	}
+	if reply := readTest(t, conn1); reply.Operation != "all" {
+		t.Fatalf("unexpected reply: %v", reply)
+	}
+	if reply := readTest(t, conn2); reply.Operation != "all" {
+		t.Fatalf("unexpected reply: %v", reply)
+	}
+}
@@ -25,73 +25,116 @@ package websocket
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log/slog"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/fasthttp/websocket"
-	"github.com/go-fox/sugar/container/smap"
+	"github.com/go-fox/sugar/util/surl"
 	"github.com/google/uuid"
 
 	"github.com/go-fox/fox/api/gen/go/protocol"
 	"github.com/go-fox/fox/codec"
 	"github.com/go-fox/fox/codec/proto"
+	"github.com/go-fox/fox/errors"
 	"github.com/go-fox/fox/middleware"
+	"github.com/go-fox/fox/registry"
+	"github.com/go-fox/fox/selector"
+	"github.com/go-fox/fox/selector/balancer/p2c"
+	_ "github.com/go-fox/fox/selector/balancer/wrr" // 注册 wrr，可以通过名称选择
 
 	"github.com/go-fox/fox/transport"
 )
 
-type callbackInfo struct {
-	data []byte
-	err  error
-}
-
 // Client ws client
 type Client struct {
-	conn       *websocket.Conn
-	endpoint   string
-	encoder    EncoderRequestFunc
-	decoder    DecodeResponseFunc
-	codec      codec.Codec
-	callbacks  *smap.Map[string, chan callbackInfo]
-	baseCtx    context.Context
-	cancel     context.CancelFunc
-	timeout    time.Duration
-	middleware []middleware.Middleware
+	mu           sync.Mutex
+	conns        map[string]*clientConn // 按服务端地址复用的连接
+	endpoint     string
+	target       *Target
+	resolver     *resolver
+	selector     selector.Selector
+	dialer       *websocket.Dialer
+	encoder      EncoderRequestFunc
+	decoder      DecodeResponseFunc
+	codec        codec.Codec
+	baseCtx      context.Context
+	cancel       context.CancelFunc
+	timeout      time.Duration
+	pingInterval time.Duration
+	reconnect    bool
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	block        bool
+	balancerName string
+	discovery    registry.Discovery
+	nodeFilters  []selector.NodeFilter
+	tlsConf      *tls.Config
+	logger       *slog.Logger
+	middleware   []middleware.Middleware
 }
 
-// NewClient create a websocket client
+// NewClient create a websocket client, endpoint like ws://127.0.0.1:8000 or discovery:///service
 func NewClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
 	context, cancel := context.WithCancel(ctx)
 	c := &Client{
-		baseCtx:   context,
-		cancel:    cancel,
-		callbacks: smap.New[string, chan callbackInfo](true),
-		timeout:   5 * time.Second,
-		codec:     proto.Codec{},
-		encoder:   DefaultRequestEncoder,
-		decoder:   DefaultResponseDecoder,
+		baseCtx:      context,
+		cancel:       cancel,
+		conns:        make(map[string]*clientConn),
+		timeout:      5 * time.Second,
+		codec:        proto.Codec{},
+		encoder:      DefaultRequestEncoder,
+		decoder:      DefaultResponseDecoder,
+		reconnect:    true,
+		minBackoff:   100 * time.Millisecond,
+		maxBackoff:   10 * time.Second,
+		block:        true,
+		balancerName: p2c.Name,
+		logger:       slog.With(slog.String("mod", "transport.websocket")),
 	}
 	for _, opt := range opts {
 		opt(c)
 	}
+	dialer := *websocket.DefaultDialer
+	dialer.TLSClientConfig = c.tlsConf
+	c.dialer = &dialer
 
-	conn, _, err := websocket.DefaultDialer.Dial(c.endpoint, nil)
+	insecure := c.tlsConf == nil
+	if !strings.Contains(c.endpoint, "://") {
+		c.endpoint = surl.Scheme("ws", !insecure) + "://" + c.endpoint
+	}
+	target, err := parseTarget(c.endpoint, insecure)
 	if err != nil {
+		cancel()
+		return nil, err
+	}
+	c.target = target
+	if c.discovery != nil && target.Scheme == "discovery" {
+		builder := selector.Get(c.balancerName)
+		if builder == nil {
+			cancel()
+			return nil, fmt.Errorf("websocket: balancer %s not registered", c.balancerName)
+		}
+		c.selector = builder.Build()
+		if c.resolver, err = newResolver(c.baseCtx, c.logger, c.discovery, target, c.selector, c.prune, c.block, insecure); err != nil {
+			cancel()
+			return nil, err
+		}
+		return c, nil
+	}
+	if _, err = c.connect(c.endpoint); err != nil {
+		cancel()
 		return nil, err
 	}
-	c.conn = conn
-	c.readMessage()
 	return c, nil
 }
 
 // Invoke makes a rpc call procedure for remote service.
 func (c *Client) Invoke(ctx context.Context, operation string, args any, reply any, opts ...CallOption) error {
-	var (
-		cancel context.CancelFunc
-		data   []byte
-	)
-	ctx, cancel = context.WithTimeout(ctx, c.timeout)
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 	req := protocol.AcquireRequest()
 	req.Operation = operation
@@ -101,103 +144,159 @@ func (c *Client) Invoke(ctx context.Context, operation string, args any, reply a
 			return err
 		}
 	}
-	if args != nil {
-		encoder, err := c.encoder(ctx, args)
-		if err != nil {
-			return err
-		}
-		data = encoder
+	if req.Metadata == nil {
+		req.Metadata = make(map[string]string)
 	}
-	req.Data = data
 	ctx = transport.NewClientContext(ctx, &Transport{
 		endpoint:  c.endpoint,
 		operation: operation,
 		req:       req,
+		reply:     &protocol.Reply{Metadata: make(map[string]string)},
 	})
-	return c.invoke(ctx, req, reply, opts...)
+	return c.invoke(ctx, req, args, reply, opts...)
 }
 
-func (c *Client) invoke(ctx context.Context, req *protocol.Request, reply any, opts ...CallOption) error {
+// invoke 中间件处理的是调用参数 args，请求数据在最内层的 handler 中编码
+func (c *Client) invoke(ctx context.Context, req *protocol.Request, args any, reply any, opts ...CallOption) error {
 	h := func(ctx context.Context, in any) (any, error) {
-		req.Id = uuid.NewString()
-		body, err := c.codec.Marshal(req)
+		req.Data = nil
+		if in != nil {
+			data, err := c.encoder(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			req.Data = data
+		}
+		cc, done, err := c.pick(ctx)
 		if err != nil {
 			return nil, err
 		}
-		if err := c.conn.WriteMessage(websocket.BinaryMessage, body); err != nil {
-			return nil, err
+		tr, _ := transport.FromClientContext(ctx)
+		wt, _ := tr.(*Transport)
+		if wt != nil {
+			wt.endpoint = cc.endpoint
+		}
+		info := c.call(ctx, cc, req)
+		if done != nil {
+			done(ctx, selector.DoneInfo{Err: info.err, ReplyMD: headerCarrier(info.md)})
 		}
-		channel := make(chan callbackInfo)
-		defer close(channel)
-		c.callbacks.Set(req.Id, channel)
-		defer c.callbacks.Del(req.Id)
-		select {
-		case <-c.baseCtx.Done():
-			return nil, c.baseCtx.Err()
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case info := <-channel:
-			if info.err != nil {
-				return nil, info.err
+		if info.err != nil {
+			return nil, info.err
+		}
+		if wt != nil {
+			for k, v := range info.md {
+				wt.reply.Metadata[k] = v
 			}
-			if err := c.decoder(ctx, info.data, reply); err != nil {
-				return nil, err
+			for _, opt := range opts {
+				opt.after(req, wt.reply)
 			}
 		}
+		if err := c.decoder(ctx, info.data, reply); err != nil {
+			return nil, err
+		}
 		return reply, nil
 	}
+	var p selector.Peer
+	ctx = selector.NewPeerContext(ctx, &p)
 	if len(c.middleware) > 0 {
 		h = middleware.Chain(c.middleware...)(h)
 	}
-	_, err := h(ctx, req)
+	_, err := h(ctx, args)
 	return err
 }
 
-func (c *Client) readMessage() {
-	go func() {
-		for {
-			select {
-			case <-c.baseCtx.Done():
-				return
-			default:
-				messageType, data, err := c.conn.ReadMessage()
-				if err != nil {
-					return
-				}
-				if messageType == websocket.BinaryMessage {
-					c.handlerReply(data)
-				}
-			}
-		}
-	}()
+// call 通过连接发送请求并等待回复
+func (c *Client) call(ctx context.Context, cc *clientConn, req *protocol.Request) callbackInfo {
+	req.Id = uuid.NewString()
+	body, err := c.codec.Marshal(req)
+	if err != nil {
+		return callbackInfo{err: err}
+	}
+	// 缓冲为 1，回复和断线通知都不会阻塞读取协程
+	channel := make(chan callbackInfo, 1)
+	if err := cc.send(req.Id, channel, body); err != nil {
+		return callbackInfo{err: err}
+	}
+	defer cc.callbacks.Del(req.Id)
+	select {
+	case <-c.baseCtx.Done():
+		return callbackInfo{err: c.baseCtx.Err()}
+	case <-ctx.Done():
+		return callbackInfo{err: ctx.Err()}
+	case info := <-channel:
+		return info
+	}
 }
 
-func (c *Client) handlerReply(data []byte) {
-	reply := protocol.AcquireReply()
-	defer protocol.ReleaseReply(reply)
-	err := c.codec.Unmarshal(data, reply)
+// pick 选择连接，使用服务发现时通过负载均衡选择节点
+func (c *Client) pick(ctx context.Context) (*clientConn, selector.DoneFunc, error) {
+	if c.resolver == nil {
+		cc, err := c.connect(c.endpoint)
+		return cc, nil, err
+	}
+	node, done, err := c.selector.Select(ctx, selector.WithNodeFilter(c.nodeFilters...))
 	if err != nil {
-		slog.Error("proto unmarshal fail", slog.Any("error", err))
-		return
+		return nil, nil, errors.ServiceUnavailable("NODE_NOT_FOUND", err.Error())
 	}
-	v, ok := c.callbacks.Get(reply.Id)
-	if !ok {
-		slog.Error(fmt.Sprintf("not found request id %s", reply.Id))
-		return
+	cc, err := c.connect(node.Scheme() + "://" + node.Address())
+	if err != nil {
+		done(ctx, selector.DoneInfo{Err: err})
+		return nil, nil, err
 	}
-	v <- callbackInfo{
-		err:  nil,
-		data: reply.Data,
+	return cc, done, nil
+}
+
+// connect 获取到 endpoint 的连接，连接不可用时重新连接
+func (c *Client) connect(endpoint string) (*clientConn, error) {
+	c.mu.Lock()
+	cc, ok := c.conns[endpoint]
+	c.mu.Unlock()
+	if ok && cc.alive() {
+		return cc, nil
+	}
+	cc, err := dialConn(c, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old, ok := c.conns[endpoint]; ok && old.alive() {
+		_ = cc.Close()
+		return old, nil
+	}
+	c.conns[endpoint] = cc
+	return cc, nil
+}
+
+// prune 关闭已下线节点的连接
+func (c *Client) prune(nodes []selector.Node) {
+	endpoints := make(map[string]struct{}, len(nodes))
+	for _, node := range nodes {
+		endpoints[node.Scheme()+"://"+node.Address()] = struct{}{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for endpoint, cc := range c.conns {
+		if _, ok := endpoints[endpoint]; !ok {
+			_ = cc.Close()
+			delete(c.conns, endpoint)
+		}
 	}
 }
 
 // Close is websocket conn close func.
 func (c *Client) Close() error {
-	if c.conn != nil {
-		return c.conn.Close()
-	}
 	if c.cancel != nil {
 		c.cancel()
 	}
+	if c.resolver != nil {
+		_ = c.resolver.Close()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for endpoint, cc := range c.conns {
+		_ = cc.Close()
+		delete(c.conns, endpoint)
+	}
 	return nil
 }
@@ -0,0 +1,131 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-fox/fox/api/gen/go/protocol"
+	"github.com/go-fox/fox/errors"
+	"github.com/go-fox/fox/middleware"
+	"github.com/go-fox/fox/registry"
+	"github.com/go-fox/fox/transport"
+)
+
+type testDiscovery struct {
+	services []*registry.ServiceInstance
+}
+
+func (d *testDiscovery) GetService(context.Context, string) ([]*registry.ServiceInstance, error) {
+	return d.services, nil
+}
+
+func (d *testDiscovery) Watch(ctx context.Context, _ string) (registry.Watcher, error) {
+	return &testWatcher{ctx: ctx, services: d.services}, nil
+}
+
+type testWatcher struct {
+	ctx      context.Context
+	services []*registry.ServiceInstance
+	sent     bool
+}
+
+func (w *testWatcher) Next() ([]*registry.ServiceInstance, error) {
+	if !w.sent {
+		w.sent = true
+		return w.services, nil
+	}
+	<-w.ctx.Done()
+	return nil, w.ctx.Err()
+}
+
+func (w *testWatcher) Stop() error {
+	return nil
+}
+
+func TestClientDiscovery(t *testing.T) {
+	discovery := &testDiscovery{}
+	for _, name := range []string{"node1", "node2"} {
+		srv, endpoint := newTestServer(t)
+		srv.Handler("echo", func(ctx Context) error {
+			return ctx.Result(&protocol.Request{Operation: name})
+		})
+		srv.Handler("fail", func(ctx Context) error {
+			return errors.NotFound("USER_NOT_FOUND", "user not found")
+		})
+		discovery.services = append(discovery.services, &registry.ServiceInstance{
+			ID:        name,
+			Name:      "helloworld",
+			Endpoints: []string{"http://127.0.0.1:1", endpoint},
+		})
+	}
+
+	var calls int
+	client, err := NewClient(context.Background(),
+		WithEndpoint("discovery:///helloworld"),
+		WithDiscovery(discovery),
+		WithMiddleware(func(handler middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req any) (any, error) {
+				calls++
+				if tr, ok := transport.FromClientContext(ctx); !ok || tr.Kind() != KindWebsocket {
+					t.Fatalf("client transport = %v, want websocket", tr)
+				}
+				return handler(ctx, req)
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	nodes := make(map[string]int)
+	for i := 0; i < 10; i++ {
+		reply := &protocol.Request{}
+		if err := client.Invoke(context.Background(), "echo", nil, reply); err != nil {
+			t.Fatal(err)
+		}
+		nodes[reply.Operation]++
+	}
+	if len(nodes) != 2 || calls != 10 {
+		t.Fatalf("nodes = %v, calls = %d, want both nodes and 10 calls", nodes, calls)
+	}
+	if err := client.Invoke(context.Background(), "fail", nil, &protocol.Request{}); errors.Reason(err) != "USER_NOT_FOUND" {
+		t.Fatalf("err = %v, want USER_NOT_FOUND", err)
+	}
+}
+
+func TestClientMiddlewareArgs(t *testing.T) {
+	srv, endpoint := newTestServer(t)
+	srv.Handler("echo", func(ctx Context) error {
+		in := &protocol.Request{}
+		if err := ctx.Bind(in); err != nil {
+			return err
+		}
+		return ctx.Result(in)
+	})
+	// 中间件拿到的是调用参数，修改后的参数会被编码发送
+	client, err := NewClient(context.Background(),
+		WithEndpoint(endpoint),
+		WithMiddleware(func(handler middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req any) (any, error) {
+				args, ok := req.(*protocol.Request)
+				if !ok || args.Operation != "hello" {
+					t.Fatalf("args = %#v, want the call args", req)
+				}
+				return handler(ctx, &protocol.Request{Operation: args.Operation + " world"})
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	reply := &protocol.Request{}
+	if err := client.Invoke(context.Background(), "echo", &protocol.Request{Operation: "hello"}, reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Operation != "hello world" {
+		t.Fatalf("reply = %q, want the args changed by middleware", reply.Operation)
+	}
+}
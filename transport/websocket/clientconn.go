@@ -0,0 +1,259 @@
+// Package websocket
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/go-fox/sugar/container/smap"
+	"google.golang.org/grpc/status"
+
+	"github.com/go-fox/fox/api/gen/go/protocol"
+	"github.com/go-fox/fox/errors"
+)
+
+type callbackInfo struct {
+	data []byte
+	md   map[string]string
+	err  error
+}
+
+// clientConn 到单个服务端地址的连接，断线后按退避时间重连
+type clientConn struct {
+	client    *Client
+	endpoint  string
+	mu        sync.Mutex      // 保护 conn，并保证同一时间只有一个协程写入连接
+	conn      *websocket.Conn // 断线重连期间为 nil
+	callbacks *smap.Map[string, chan callbackInfo]
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+// dialConn 连接服务端地址
+func dialConn(c *Client, endpoint string) (*clientConn, error) {
+	ctx, cancel := context.WithCancel(c.baseCtx)
+	conn, _, err := c.dialer.DialContext(ctx, endpoint, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	cc := &clientConn{
+		client:    c,
+		endpoint:  endpoint,
+		callbacks: smap.New[string, chan callbackInfo](true),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+	cc.setupConn(conn)
+	cc.conn = conn
+	go cc.serve(conn)
+	return cc, nil
+}
+
+// send 注册回调并写入请求，断线期间直接返回错误
+func (cc *clientConn) send(id string, channel chan callbackInfo, body []byte) error {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.conn == nil {
+		return cc.errDisconnected()
+	}
+	cc.callbacks.Set(id, channel)
+	if err := cc.conn.WriteMessage(websocket.BinaryMessage, body); err != nil {
+		cc.callbacks.Del(id)
+		return err
+	}
+	return nil
+}
+
+// alive 连接是否可用，关闭后或未开启重连时断线后不可用
+func (cc *clientConn) alive() bool {
+	return cc.ctx.Err() == nil
+}
+
+// serve 读取消息，连接断开后按退避时间重连
+func (cc *clientConn) serve(conn *websocket.Conn) {
+	defer cc.cancel()
+	for conn != nil {
+		stop := cc.keepalive(conn)
+		cc.readMessage(conn)
+		stop()
+		cc.disconnect(conn)
+		conn = cc.redial()
+	}
+}
+
+func (cc *clientConn) readMessage(conn *websocket.Conn) {
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		cc.extendReadDeadline(conn)
+		if messageType == websocket.BinaryMessage {
+			cc.handlerReply(data)
+		}
+	}
+}
+
+// keepalive 定时发送 ping，连接关闭时断开连接，返回的函数停止并等待协程退出
+func (cc *clientConn) keepalive(conn *websocket.Conn) func() {
+	interval := cc.client.pingInterval
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		var pingC <-chan time.Time
+		if interval > 0 {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			pingC = ticker.C
+		}
+		for {
+			select {
+			case <-done:
+				return
+			case <-cc.ctx.Done():
+				_ = conn.Close()
+				return
+			case <-pingC:
+				cc.mu.Lock()
+				err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(interval))
+				cc.mu.Unlock()
+				if err != nil {
+					_ = conn.Close()
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-exited
+	}
+}
+
+// disconnect 关闭连接，并让等待中的请求返回断线错误
+func (cc *clientConn) disconnect(conn *websocket.Conn) {
+	_ = conn.Close()
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.conn == conn {
+		cc.conn = nil
+	}
+	err := cc.errDisconnected()
+	cc.callbacks.DeleteWith(func(_ string, channel chan callbackInfo) bool {
+		select {
+		case channel <- callbackInfo{err: err}:
+		default:
+		}
+		return true
+	})
+}
+
+// redial 按指数退避重新连接，未开启重连或连接已关闭时返回 nil
+func (cc *clientConn) redial() *websocket.Conn {
+	c := cc.client
+	if !c.reconnect {
+		return nil
+	}
+	backoff := c.minBackoff
+	for {
+		select {
+		case <-cc.ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		conn, _, err := c.dialer.DialContext(cc.ctx, cc.endpoint, nil)
+		if err == nil {
+			cc.setupConn(conn)
+			cc.mu.Lock()
+			cc.conn = conn
+			cc.mu.Unlock()
+			return conn
+		}
+		c.logger.Warn("websocket reconnect fail", "endpoint", cc.endpoint, "error", err)
+		backoff = min(backoff*2, c.maxBackoff)
+	}
+}
+
+// setupConn 开启 ping 时设置读取超时时间，收到 pong 或消息后顺延
+func (cc *clientConn) setupConn(conn *websocket.Conn) {
+	if cc.client.pingInterval <= 0 {
+		return
+	}
+	conn.SetPongHandler(func(string) error {
+		cc.extendReadDeadline(conn)
+		return nil
+	})
+	cc.extendReadDeadline(conn)
+}
+
+func (cc *clientConn) extendReadDeadline(conn *websocket.Conn) {
+	if interval := cc.client.pingInterval; interval > 0 {
+		_ = conn.SetReadDeadline(time.Now().Add(interval * 2))
+	}
+}
+
+func (cc *clientConn) errDisconnected() error {
+	return errors.ServiceUnavailable("WEB_SOCKET_DISCONNECTED", fmt.Sprintf("websocket: %s disconnected", cc.endpoint))
+}
+
+func (cc *clientConn) handlerReply(data []byte) {
+	reply := protocol.AcquireReply()
+	defer protocol.ReleaseReply(reply)
+	err := cc.client.codec.Unmarshal(data, reply)
+	if err != nil {
+		cc.client.logger.Error("proto unmarshal fail", "error", err)
+		return
+	}
+	v, ok := cc.callbacks.Get(reply.Id)
+	if !ok {
+		cc.client.logger.Error(fmt.Sprintf("not found request id %s", reply.Id))
+		return
+	}
+	info := callbackInfo{data: reply.Data, md: reply.Metadata}
+	if reply.Status != nil && reply.Status.Code != 0 {
+		info.err = errors.FromError(status.FromProto(reply.Status).Err())
+	}
+	select {
+	case v <- info:
+	default:
+	}
+}
+
+// Close 关闭连接，不再重连
+func (cc *clientConn) Close() error {
+	cc.cancel()
+	cc.mu.Lock()
+	conn := cc.conn
+	cc.mu.Unlock()
+	if conn != nil {
+		return conn.Close()
+	}
+	return nil
+}
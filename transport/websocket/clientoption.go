@@ -25,10 +25,15 @@ package websocket
 
 import (
 	"context"
+	"crypto/tls"
+	"log/slog"
 	"time"
 
 	"github.com/go-fox/fox/codec"
 	"github.com/go-fox/fox/codec/proto"
+	"github.com/go-fox/fox/middleware"
+	"github.com/go-fox/fox/registry"
+	"github.com/go-fox/fox/selector"
 	"github.com/go-fox/fox/transport"
 )
 
@@ -107,3 +112,75 @@ func WithCodec(codec codec.Codec) ClientOption {
 		c.codec = codec
 	}
 }
+
+// WithPingInterval with client ping interval, the connection is closed and reconnected
+// when no pong or message is received within twice the interval
+func WithPingInterval(interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.pingInterval = interval
+	}
+}
+
+// WithReconnect with client auto reconnect, enabled by default
+func WithReconnect(reconnect bool) ClientOption {
+	return func(c *Client) {
+		c.reconnect = reconnect
+	}
+}
+
+// WithBackoff with client reconnect backoff, the delay doubles from min up to max
+func WithBackoff(min, max time.Duration) ClientOption {
+	return func(c *Client) {
+		c.minBackoff = min
+		c.maxBackoff = max
+	}
+}
+
+// WithBlock with a client block option, wait for the first discovery result when creating
+func WithBlock(block bool) ClientOption {
+	return func(c *Client) {
+		c.block = block
+	}
+}
+
+// WithBalancerName with a client balancer name option
+func WithBalancerName(balancerName string) ClientOption {
+	return func(c *Client) {
+		c.balancerName = balancerName
+	}
+}
+
+// WithDiscovery with a discovery option, endpoint like discovery:///service
+func WithDiscovery(discovery registry.Discovery) ClientOption {
+	return func(c *Client) {
+		c.discovery = discovery
+	}
+}
+
+// WithNodeFilters with select filters
+func WithNodeFilters(nodeFilters ...selector.NodeFilter) ClientOption {
+	return func(c *Client) {
+		c.nodeFilters = nodeFilters
+	}
+}
+
+// WithMiddleware with client middleware
+func WithMiddleware(mws ...middleware.Middleware) ClientOption {
+	return func(c *Client) {
+		c.middleware = mws
+	}
+}
+
+// WithTLSConfig with tls config
+func WithTLSConfig(tlsConf *tls.Config) ClientOption {
+	return func(c *Client) {
+		c.tlsConf = tlsConf
+	}
+}
+
+// WithLogger with client logger
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
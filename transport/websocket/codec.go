@@ -60,6 +60,7 @@ func DefaultResponseEncoder(ss *Session, r *protocol.Request, reply *protocol.Re
 func DefaultErrorEncoder(ss *Session, r *protocol.Request, err error) {
 	fromError := errors.FromError(err)
 	reply := protocol.AcquireReply()
+	reply.Id = r.Id
 	reply.Operation = r.Operation
 	reply.Status = fromError.GRPCStatus().Proto()
 	_ = ss.Send(reply)
@@ -47,6 +47,7 @@ type Context interface {
 	context.Context
 	Result(data any) error
 	Bind(any) error
+	Session() *Session
 }
 
 type wrappedContext struct {
@@ -73,7 +74,7 @@ func releaseContext(ctx *wrappedContext) {
 
 // Middleware run middleware
 func (w *wrappedContext) Middleware(h middleware.Handler) middleware.Handler {
-	return middleware.Chain(w.srv.config.ms...)(h)
+	return middleware.Chain(w.srv.config.middleware.Match(w.request.Operation)...)(h)
 }
 
 // Result writes data to a client
@@ -81,6 +82,11 @@ func (w *wrappedContext) Result(data any) error {
 	return w.srv.config.enc(w.ss, w.request, w.reply, data)
 }
 
+// Session returns the client session
+func (w *wrappedContext) Session() *Session {
+	return w.ss
+}
+
 // Bind bind data
 func (w *wrappedContext) Bind(data any) error {
 	return w.srv.config.dec(w.request, data)
@@ -0,0 +1,57 @@
+// Package websocket
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package websocket
+
+import (
+	"net/url"
+
+	"github.com/valyala/fasthttp"
+	"google.golang.org/grpc/metadata"
+)
+
+// handshake 握手请求信息，连接升级后 fasthttp.RequestCtx 会被回收，需要提前复制
+type handshake struct {
+	header  metadata.MD
+	query   url.Values
+	cookies map[string]string
+}
+
+// newHandshake 复制握手请求的请求头、查询参数和 cookie
+func newHandshake(ctx *fasthttp.RequestCtx) *handshake {
+	hs := &handshake{
+		header:  metadata.MD{},
+		query:   url.Values{},
+		cookies: make(map[string]string),
+	}
+	ctx.Request.Header.VisitAll(func(key, value []byte) {
+		hs.header.Append(string(key), string(value))
+	})
+	ctx.QueryArgs().VisitAll(func(key, value []byte) {
+		hs.query.Add(string(key), string(value))
+	})
+	ctx.Request.Header.VisitAllCookie(func(key, value []byte) {
+		hs.cookies[string(key)] = string(value)
+	})
+	return hs
+}
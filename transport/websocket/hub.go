@@ -0,0 +1,295 @@
+// Package websocket
+// MIT License
+//
+// # Copyright (c) 2024 go-fox
+// Author https://github.com/go-fox/fox
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package websocket
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-fox/fox/api/gen/go/protocol"
+	"github.com/go-fox/fox/errors"
+)
+
+// hub 在线 session 和房间的注册表
+type hub struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+	rooms    map[string]map[string]*Session
+}
+
+func newHub() *hub {
+	return &hub{
+		sessions: make(map[string]*Session),
+		rooms:    make(map[string]map[string]*Session),
+	}
+}
+
+// register 注册 session，max 大于 0 时同一元数据值的 session 数量达到 max 后拒绝注册
+func (h *hub) register(ss *Session, key string, max int) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if value := ss.Load(key); key != "" && max > 0 && value != "" {
+		count := 0
+		for _, other := range h.sessions {
+			if other.Load(key) == value {
+				count++
+			}
+		}
+		if count >= max {
+			return false
+		}
+	}
+	h.sessions[ss.ID()] = ss
+	return true
+}
+
+// unregister 注销 session，并退出所有房间
+func (h *hub) unregister(ss *Session) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for room := range ss.rooms {
+		h.leave(room, ss)
+	}
+	delete(h.sessions, ss.ID())
+}
+
+// get 根据 id 获取 session
+func (h *hub) get(id string) (*Session, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	ss, ok := h.sessions[id]
+	return ss, ok
+}
+
+// join 把 session 加入房间
+func (h *hub) join(room string, id string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ss, ok := h.sessions[id]
+	if !ok {
+		return errSessionNotFound(id)
+	}
+	members, ok := h.rooms[room]
+	if !ok {
+		members = make(map[string]*Session)
+		h.rooms[room] = members
+	}
+	members[id] = ss
+	if ss.rooms == nil {
+		ss.rooms = make(map[string]struct{})
+	}
+	ss.rooms[room] = struct{}{}
+	return nil
+}
+
+// leaveById 把 session 移出房间
+func (h *hub) leaveById(room string, id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if ss, ok := h.sessions[id]; ok {
+		h.leave(room, ss)
+	}
+}
+
+// leave 把 session 移出房间，调用方需持有写锁
+func (h *hub) leave(room string, ss *Session) {
+	delete(ss.rooms, room)
+	members, ok := h.rooms[room]
+	if !ok {
+		return
+	}
+	delete(members, ss.ID())
+	if len(members) == 0 {
+		delete(h.rooms, room)
+	}
+}
+
+// target 推送目标，记录推送时的 session id，用于识别被回收复用的 session
+type target struct {
+	id string
+	ss *Session
+}
+
+// snapshot 获取房间内 session 的快照，room 为空时返回所有 session
+func (h *hub) snapshot(room string) []target {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	members := h.sessions
+	if room != "" {
+		members = h.rooms[room]
+	}
+	targets := make([]target, 0, len(members))
+	for id, ss := range members {
+		targets = append(targets, target{id: id, ss: ss})
+	}
+	return targets
+}
+
+// find 查找元数据 key 等于 value 的 session
+func (h *hub) find(key, value string) []target {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	res := make([]target, 0)
+	for id, ss := range h.sessions {
+		if ss.Load(key) == value {
+			res = append(res, target{id: id, ss: ss})
+		}
+	}
+	return res
+}
+
+// members 获取房间内的 session id
+func (h *hub) members(room string) []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	ids := make([]string, 0, len(h.rooms[room]))
+	for id := range h.rooms[room] {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Session 根据 id 获取在线的 session
+func (s *Server) Session(id string) (SessionRef, bool) {
+	ss, ok := s.hub.get(id)
+	if !ok {
+		return SessionRef{}, false
+	}
+	return SessionRef{id: id, ss: ss}, true
+}
+
+// FindSessions 查找通过 Session.Store 保存的元数据 key 等于 value 的本节点 session，例如按用户id查找
+func (s *Server) FindSessions(key, value string) []SessionRef {
+	targets := s.hub.find(key, value)
+	res := make([]SessionRef, 0, len(targets))
+	for _, t := range targets {
+		res = append(res, SessionRef{id: t.id, ss: t.ss})
+	}
+	return res
+}
+
+// Join 把 session 加入房间
+func (s *Server) Join(room string, sessionId string) error {
+	return s.hub.join(room, sessionId)
+}
+
+// Leave 把 session 移出房间，session 断开时会自动退出所有房间
+func (s *Server) Leave(room string, sessionId string) {
+	s.hub.leaveById(room, sessionId)
+}
+
+// RoomMembers 获取本节点房间内的 session id
+func (s *Server) RoomMembers(room string) []string {
+	return s.hub.members(room)
+}
+
+// Push 向指定 session 推送消息，session 不在本节点时通过 Broker 转发
+func (s *Server) Push(sessionId string, reply *protocol.Reply) error {
+	message, err := s.config.codec.Marshal(reply)
+	if err != nil {
+		return err
+	}
+	if ss, ok := s.hub.get(sessionId); ok {
+		return ss.writeTo(sessionId, message)
+	}
+	if s.config.broker == nil {
+		return errSessionNotFound(sessionId)
+	}
+	return s.config.broker.Publish(context.Background(), &BrokerMessage{SessionId: sessionId, Data: message})
+}
+
+// SendTo 向元数据 key 等于 value 的所有 session 推送消息，例如推送给某个用户的所有连接，
+// 配置 Broker 时推送到整个集群
+func (s *Server) SendTo(key, value string, operation string, payload any) error {
+	message, err := s.encodePush(operation, payload)
+	if err != nil {
+		return err
+	}
+	return s.dispatch(&BrokerMessage{Key: key, Value: value, Data: message})
+}
+
+// Broadcast 向房间内的所有 session 推送消息，room 为空时推送给所有在线 session，
+// payload 为 []byte 时原样发送，否则使用 PushCodec 编码，单个 session 推送失败不影响其他 session，
+// 配置 Broker 时推送到整个集群
+func (s *Server) Broadcast(room string, operation string, payload any) error {
+	message, err := s.encodePush(operation, payload)
+	if err != nil {
+		return err
+	}
+	return s.dispatch(&BrokerMessage{Room: room, Data: message})
+}
+
+// encodePush 编码推送消息
+func (s *Server) encodePush(operation string, payload any) ([]byte, error) {
+	reply := protocol.AcquireReply()
+	defer protocol.ReleaseReply(reply)
+	reply.Operation = operation
+	switch v := payload.(type) {
+	case nil:
+	case []byte:
+		reply.Data = v
+	default:
+		data, err := s.config.pushCodec.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		reply.Data = data
+		reply.Metadata["Content-Type"] = s.config.pushCodec.Name()
+	}
+	// 只编码一次
+	return s.config.codec.Marshal(reply)
+}
+
+// dispatch 配置 Broker 时发布到集群，否则直接投递给本节点
+func (s *Server) dispatch(msg *BrokerMessage) error {
+	if s.config.broker == nil {
+		s.deliver(msg)
+		return nil
+	}
+	return s.config.broker.Publish(context.Background(), msg)
+}
+
+// deliver 投递消息给本节点的 session
+func (s *Server) deliver(msg *BrokerMessage) {
+	var targets []target
+	switch {
+	case msg.SessionId != "":
+		if ss, ok := s.hub.get(msg.SessionId); ok {
+			targets = []target{{id: msg.SessionId, ss: ss}}
+		}
+	case msg.Key != "":
+		targets = s.hub.find(msg.Key, msg.Value)
+	default:
+		targets = s.hub.snapshot(msg.Room)
+	}
+	for _, t := range targets {
+		if err := t.ss.writeTo(t.id, msg.Data); err != nil && !errors.IsClientClosed(err) {
+			s.config.logger.Warn("[WS] push error", "session", t.id, "room", msg.Room, "error", err)
+		}
+	}
+}
+
+func errSessionNotFound(id string) error {
+	return errors.NotFound("WEB_SOCKET_SESSION_NOT_FOUND", "session: "+id+" not found")
+}
@@ -0,0 +1,140 @@
+package websocket
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+
+	"github.com/go-fox/fox/api/gen/go/protocol"
+	"github.com/go-fox/fox/codec/proto"
+)
+
+// newTestServer 启动一个注册了 join 操作的测试服务，join 会保存 uid 并加入 room
+func newTestServer(t *testing.T, opts ...ServerOption) (*Server, string) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(append(opts, Listener(lis))...)
+	srv.Handler("join", func(ctx Context) error {
+		ctx.Session().Store("uid", "1001")
+		return srv.Join("room", ctx.Session().ID())
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		_ = srv.Start(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		_ = srv.Stop(context.Background())
+	})
+	return srv, "ws://" + lis.Addr().String()
+}
+
+func dialTest(t *testing.T, endpoint string) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial(endpoint, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return conn
+}
+
+func joinTest(t *testing.T, srv *Server, conn *websocket.Conn) {
+	join, _ := (proto.Codec{}).Marshal(&protocol.Request{Id: "1", Operation: "join"})
+	if err := conn.WriteMessage(websocket.BinaryMessage, join); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(srv.RoomMembers("room")) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func readTest(t *testing.T, conn *websocket.Conn) *protocol.Reply {
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, message, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply := &protocol.Reply{}
+	if err := (proto.Codec{}).Unmarshal(message, reply); err != nil {
+		t.Fatal(err)
+	}
+	return reply
+}
+
+func TestBroadcast(t *testing.T) {
+	srv, endpoint := newTestServer(t)
+	member, other := dialTest(t, endpoint), dialTest(t, endpoint)
+	joinTest(t, srv, member)
+	if n := srv.SessionCount(); n != 2 {
+		t.Fatalf("session count = %d, want 2", n)
+	}
+
+	if err := srv.Broadcast("room", "notice", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if reply := readTest(t, member); reply.Operation != "notice" || string(reply.Data) != "hello" {
+		t.Fatalf("unexpected reply: %v", reply)
+	}
+
+	found := srv.FindSessions("uid", "1001")
+	if len(found) != 1 {
+		t.Fatalf("found %d sessions, want 1", len(found))
+	}
+	if err := srv.Push(found[0].ID(), &protocol.Reply{Operation: "direct"}); err != nil {
+		t.Fatal(err)
+	}
+	if reply := readTest(t, member); reply.Operation != "direct" {
+		t.Fatalf("unexpected reply: %v", reply)
+	}
+
+	if err := srv.Broadcast("", "all", nil); err != nil {
+		t.Fatal(err)
+	}
+	if reply := readTest(t, other); reply.Operation != "all" {
+		t.Fatalf("unexpected reply: %v", reply)
+	}
+}
+
+func TestSessionRefAfterClose(t *testing.T) {
+	srv, endpoint := newTestServer(t)
+	first := dialTest(t, endpoint)
+	joinTest(t, srv, first)
+	refs := srv.FindSessions("uid", "1001")
+	if len(refs) != 1 {
+		t.Fatalf("found %d sessions, want 1", len(refs))
+	}
+	ref := refs[0]
+	if ref.Load("uid") != "1001" {
+		t.Fatalf("uid = %q, want 1001", ref.Load("uid"))
+	}
+
+	_ = first.Close()
+	deadline := time.Now().Add(time.Second)
+	for srv.SessionCount() != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	// 新连接可能复用已释放的 session，旧引用不能写入新连接
+	second := dialTest(t, endpoint)
+	joinTest(t, srv, second)
+	if err := ref.Send(&protocol.Reply{Operation: "stale"}); err == nil {
+		t.Fatal("send through stale ref should fail")
+	}
+	if v := ref.Load("uid"); v != "" {
+		t.Fatalf("stale ref load = %q, want empty", v)
+	}
+	_ = ref.Close()
+	if err := srv.Broadcast("", "alive", nil); err != nil {
+		t.Fatal(err)
+	}
+	if reply := readTest(t, second); reply.Operation != "alive" {
+		t.Fatalf("unexpected reply: %v", reply)
+	}
+}
@@ -0,0 +1,153 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+
+	"github.com/go-fox/fox/api/gen/go/protocol"
+	"github.com/go-fox/fox/errors"
+)
+
+func TestMaxConnections(t *testing.T) {
+	_, endpoint := newTestServer(t, MaxConnections(1))
+	dialTest(t, endpoint)
+	if _, _, err := websocket.DefaultDialer.Dial(endpoint, nil); err == nil {
+		t.Fatal("dial over max connections, want error")
+	}
+}
+
+func TestMaxSessionsPerKey(t *testing.T) {
+	_, endpoint := newTestServer(t,
+		Authorization(func(ss *Session) error {
+			ss.Store("uid", ss.Query("uid"))
+			return nil
+		}),
+		MaxSessionsPerKey("uid", 1),
+	)
+	dialTest(t, endpoint+"?uid=1001")
+	dialTest(t, endpoint+"?uid=1002")
+	conn := dialTest(t, endpoint+"?uid=1001")
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+		t.Fatalf("err = %v, want policy violation", err)
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	srv, endpoint := newTestServer(t, IdleTimeout(100*time.Millisecond))
+	conn := dialTest(t, endpoint)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Fatalf("err = %v, want going away", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for srv.SessionCount() != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := srv.SessionCount(); n != 0 {
+		t.Fatalf("session count = %d, want 0", n)
+	}
+}
+
+func TestPing(t *testing.T) {
+	_, endpoint := newTestServer(t, PingInterval(20*time.Millisecond, time.Second))
+	conn := dialTest(t, endpoint)
+	pings := make(chan struct{}, 10)
+	conn.SetPingHandler(func(string) error {
+		select {
+		case pings <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	select {
+	case <-pings:
+	case <-time.After(time.Second):
+		t.Fatal("ping not received")
+	}
+}
+
+func TestClientReconnect(t *testing.T) {
+	srv, endpoint := newTestServer(t)
+	release := make(chan struct{})
+	srv.Handler("echo", func(ctx Context) error {
+		return ctx.Result(&protocol.Request{Operation: "pong"})
+	})
+	srv.Handler("block", func(ctx Context) error {
+		<-release
+		return nil
+	})
+	defer close(release)
+	client, err := NewClient(context.Background(),
+		WithEndpoint(endpoint),
+		WithPingInterval(50*time.Millisecond),
+		WithBackoff(10*time.Millisecond, 50*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// 断线时等待中的请求返回断线错误
+	pending := make(chan error, 1)
+	go func() {
+		pending <- client.Invoke(context.Background(), "block", nil, &protocol.Request{})
+	}()
+	cc, err := client.connect(client.endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(cc.callbacks.CopyMap()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	for _, target := range srv.hub.snapshot("") {
+		_ = target.ss.Close()
+	}
+	if err := <-pending; !errors.IsServiceUnavailable(err) {
+		t.Fatalf("err = %v, want service unavailable", err)
+	}
+
+	// 重连后可以继续调用
+	reply := &protocol.Request{}
+	deadline = time.Now().Add(time.Second)
+	for {
+		err = client.Invoke(context.Background(), "echo", nil, reply)
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil || reply.Operation != "pong" {
+		t.Fatalf("reply = %v, err = %v, want pong", reply, err)
+	}
+}
+
+func TestStopForceClose(t *testing.T) {
+	srv, endpoint := newTestServer(t)
+	conn := dialTest(t, endpoint)
+	deadline := time.Now().Add(time.Second)
+	for srv.SessionCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	// session 未在期限内断开时发送关闭帧并关闭连接
+	if err := srv.Stop(ctx); err == nil {
+		t.Fatal("stop should fail when sessions are not drained")
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Fatalf("err = %v, want going away", err)
+	}
+}
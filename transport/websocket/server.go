@@ -30,6 +30,8 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"sync/atomic"
+	"time"
 
 	"github.com/fasthttp/websocket"
 	"github.com/go-fox/sugar/container/smap"
@@ -62,6 +64,8 @@ type Server struct {
 	sessionPool *ants.Pool
 	handlerPool *ants.Pool
 	handlerMap  *smap.Map[string, HandlerFunc]
+	hub         *hub
+	sessions    int64
 }
 
 // NewServer new a websocket server by options
@@ -82,6 +86,7 @@ func NewServerWithConfig(cs ...*ServerConfig) *Server {
 	srv := &Server{
 		config:     c,
 		handlerMap: smap.New[string, HandlerFunc](),
+		hub:        newHub(),
 		srv: &fasthttp.Server{
 			Name: "websocket",
 		},
@@ -128,6 +133,11 @@ func (s *Server) Start(ctx context.Context) error {
 		return err
 	}
 	s.baseCtx = ctx
+	if c.broker != nil {
+		if err := c.broker.Subscribe(ctx, s.deliver); err != nil {
+			return err
+		}
+	}
 	c.logger.Info(fmt.Sprintf("[HTTP] server listening on: %s", c.lis.Addr().String()))
 	var err error
 	listener := c.lis
@@ -142,10 +152,33 @@ func (s *Server) Start(ctx context.Context) error {
 	return err
 }
 
-// Stop is stop this server
+// Stop is stop this server, waits for the sessions to disconnect and force closes them after ctx is done
 func (s *Server) Stop(ctx context.Context) error {
 	s.config.logger.Info("[HTTP] server stopping")
-	return s.srv.ShutdownWithContext(ctx)
+	err := s.srv.ShutdownWithContext(ctx)
+	// 劫持后的连接不受 fasthttp 管理，等待 session 断开，超过期限后强制关闭
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for s.SessionCount() > 0 {
+		select {
+		case <-ctx.Done():
+			s.closeSessions()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return err
+}
+
+// closeSessions 向所有 session 发送关闭帧并关闭连接
+func (s *Server) closeSessions() {
+	targets := s.hub.snapshot("")
+	for _, t := range targets {
+		t.ss.closeWithTo(t.id, websocket.CloseGoingAway, "server shutdown")
+	}
+	if len(targets) > 0 {
+		s.config.logger.Warn("[HTTP] force closed sessions after stop deadline", "count", len(targets))
+	}
 }
 
 // Use uses service middleware with selector.
@@ -153,6 +186,11 @@ func (s *Server) Use(selector string, m ...middleware.Middleware) {
 	s.config.middleware.Add(selector, m...)
 }
 
+// SessionCount 当前连接的 session 数量
+func (s *Server) SessionCount() int64 {
+	return atomic.LoadInt64(&s.sessions)
+}
+
 // Handler 设置handler
 func (s *Server) Handler(operator string, handler HandlerFunc) {
 	s.handlerMap.Set(operator, handler)
@@ -160,16 +198,29 @@ func (s *Server) Handler(operator string, handler HandlerFunc) {
 
 // serveWs is server websocket
 func (s *Server) serveWs(ctx *fasthttp.RequestCtx) {
-	if err := s.config.upgrader.Upgrade(ctx, s.handlerConn); err != nil {
+	// 握手前占用连接数，超过最大连接数时直接拒绝
+	count := atomic.AddInt64(&s.sessions, 1)
+	if s.config.MaxConnections > 0 && count > s.config.MaxConnections {
+		atomic.AddInt64(&s.sessions, -1)
+		ctx.Error("too many connections", fasthttp.StatusServiceUnavailable)
+		return
+	}
+	hs := newHandshake(ctx)
+	if err := s.config.upgrader.Upgrade(ctx, func(conn *websocket.Conn) {
+		defer atomic.AddInt64(&s.sessions, -1)
+		s.handlerConn(conn, hs)
+	}); err != nil {
+		atomic.AddInt64(&s.sessions, -1)
 		ctx.Error(err.Error(), fasthttp.StatusServiceUnavailable)
 	}
 }
 
 // handlerConn conn process handler
-func (s *Server) handlerConn(conn *websocket.Conn) {
+func (s *Server) handlerConn(conn *websocket.Conn, hs *handshake) {
 	// 1.借用session
-	ss := acquireSession(s.baseCtx, s.config.codec, conn)
+	ss := acquireSession(s.baseCtx, s.config.codec, conn, hs)
 	defer releaseSession(ss)
+	s.setupConn(ss)
 
 	// 2.处理客户端链接成功
 	if err := s.onConnected(ss); err != nil {
@@ -178,6 +229,12 @@ func (s *Server) handlerConn(conn *websocket.Conn) {
 		return
 	}
 	defer s.onDisconnected(ss)
+	if !s.hub.register(ss, s.config.MaxSessionsKey, s.config.MaxSessionsPerKey) {
+		ss.closeWith(websocket.ClosePolicyViolation, "too many sessions")
+		return
+	}
+	defer s.hub.unregister(ss)
+	defer s.keepalive(ss)()
 
 	// 3.读取消息，并交给handler
 	for {
@@ -196,6 +253,65 @@ func (s *Server) handlerConn(conn *websocket.Conn) {
 	}
 }
 
+// setupConn 设置连接的读写限制和超时时间
+func (s *Server) setupConn(ss *Session) {
+	c := s.config
+	ss.readTimeout = c.readTimeout()
+	ss.writeTimeout = c.WriteTimeout
+	if c.MaxMessageSize > 0 {
+		ss.conn.SetReadLimit(c.MaxMessageSize)
+	}
+	ss.conn.SetPongHandler(func(string) error {
+		ss.extendReadDeadline()
+		return nil
+	})
+	ss.extendReadDeadline()
+}
+
+// keepalive 定时发送 ping 并断开空闲的 session，返回的函数停止并等待协程退出
+func (s *Server) keepalive(ss *Session) func() {
+	c := s.config
+	if c.PingInterval <= 0 && c.IdleTimeout <= 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		var pingC, idleC <-chan time.Time
+		if c.PingInterval > 0 {
+			ticker := time.NewTicker(c.PingInterval)
+			defer ticker.Stop()
+			pingC = ticker.C
+		}
+		if c.IdleTimeout > 0 {
+			ticker := time.NewTicker(c.IdleTimeout / 4)
+			defer ticker.Stop()
+			idleC = ticker.C
+		}
+		for {
+			select {
+			case <-done:
+				return
+			case <-pingC:
+				if err := ss.ping(); err != nil {
+					_ = ss.Close()
+					return
+				}
+			case <-idleC:
+				if time.Since(ss.LastActiveTime()) >= c.IdleTimeout {
+					ss.closeWith(websocket.CloseGoingAway, "idle timeout")
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-exited
+	}
+}
+
 // onConnected client connected process handler
 func (s *Server) onConnected(ss *Session) error {
 	// 1.拦截器处理
@@ -266,7 +382,6 @@ func (s *Server) onReceiveRequest(sess *Session, req *protocol.Request) error {
 		defer cancel()
 
 		tr := &Transport{
-			endpoint:  s.endpoint.String(),
 			operation: req.Operation,
 			ss:        sess,
 			req:       req,
@@ -295,7 +410,7 @@ func (s *Server) listenAndEndpoint() error {
 		if err != nil {
 			return err
 		}
-		s.endpoint = surl.NewURL(surl.Scheme("http", s.config.tlsConf != nil), addr)
+		s.endpoint = surl.NewURL(surl.Scheme("ws", s.config.tlsConf != nil), addr)
 	}
 	return nil
 }
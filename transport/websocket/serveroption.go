@@ -56,13 +56,22 @@ type ServerConfig struct {
 	CertFile                string        `json:"cert_file"`
 	KeyFile                 string        `json:"key_file"`
 	Timeout                 time.Duration `json:"timeout"`
+	PingInterval            time.Duration `json:"ping_interval"`        // 发送 ping 的间隔，0 表示不发送
+	PongTimeout             time.Duration `json:"pong_timeout"`         // 等待 pong 或消息的超时时间，默认为两倍 ping 间隔
+	IdleTimeout             time.Duration `json:"idle_timeout"`         // 没有收发业务消息的空闲时间，超过后断开连接，0 表示不限制
+	WriteTimeout            time.Duration `json:"write_timeout"`        // 写入超时时间，0 表示不限制
+	MaxConnections          int64         `json:"max_connections"`      // 最大连接数，0 表示不限制
+	MaxMessageSize          int64         `json:"max_message_size"`     // 单条消息的最大字节数，0 表示不限制
+	MaxSessionsKey          string        `json:"max_sessions_key"`     // 限制连接数的元数据 key，例如用户编号
+	MaxSessionsPerKey       int           `json:"max_sessions_per_key"` // 同一元数据值最多的连接数，0 表示不限制
 	tlsConf                 *tls.Config
 	lis                     net.Listener
 	middleware              matcher.Matcher
 	logger                  *slog.Logger
 	upgrader                websocket.FastHTTPUpgrader
 	codec                   codec.Codec
-	ms                      []middleware.Middleware
+	pushCodec               codec.Codec
+	broker                  Broker
 	authorization           AuthorizationHandler
 	connectedInterceptor    ConnectedInterceptor
 	disconnectedInterceptor DisconnectedInterceptor
@@ -83,6 +92,7 @@ func DefaultServerConfig() *ServerConfig {
 		HandlerPoolSize: math.MaxInt32,
 		logger:          slog.With(slog.String("mod", "transport.websocket")),
 		codec:           codec.GetCodec(proto.Name),
+		pushCodec:       codec.GetCodec(proto.Name),
 		dec:             DefaultRequestDecoder,
 		enc:             DefaultResponseEncoder,
 		ene:             DefaultErrorEncoder,
@@ -107,6 +117,20 @@ func Codec(codec codec.Codec) ServerOption {
 	}
 }
 
+// PushCodec with a codec option for encoding broadcast payload
+func PushCodec(codec codec.Codec) ServerOption {
+	return func(s *ServerConfig) {
+		s.pushCodec = codec
+	}
+}
+
+// WithBroker with a broker option for cross-node push
+func WithBroker(broker Broker) ServerOption {
+	return func(s *ServerConfig) {
+		s.broker = broker
+	}
+}
+
 // Authorization with an authorization option.
 func Authorization(handler AuthorizationHandler) ServerOption {
 	return func(s *ServerConfig) {
@@ -142,6 +166,13 @@ func Address(address string) ServerOption {
 	}
 }
 
+// Listener with server lis
+func Listener(lis net.Listener) ServerOption {
+	return func(s *ServerConfig) {
+		s.lis = lis
+	}
+}
+
 // Middleware with middlewares
 func Middleware(ms ...middleware.Middleware) ServerOption {
 	return func(s *ServerConfig) {
@@ -190,3 +221,59 @@ func Logger(logger *slog.Logger) ServerOption {
 		s.logger = logger
 	}
 }
+
+// PingInterval with ping interval, pongTimeout is the read deadline after each pong or message,
+// 0 means twice the ping interval
+func PingInterval(interval, pongTimeout time.Duration) ServerOption {
+	return func(s *ServerConfig) {
+		s.PingInterval = interval
+		s.PongTimeout = pongTimeout
+	}
+}
+
+// IdleTimeout with idle timeout, sessions without business message are closed after timeout
+func IdleTimeout(timeout time.Duration) ServerOption {
+	return func(s *ServerConfig) {
+		s.IdleTimeout = timeout
+	}
+}
+
+// WriteTimeout with write deadline option
+func WriteTimeout(timeout time.Duration) ServerOption {
+	return func(s *ServerConfig) {
+		s.WriteTimeout = timeout
+	}
+}
+
+// MaxConnections with max connections option
+func MaxConnections(max int64) ServerOption {
+	return func(s *ServerConfig) {
+		s.MaxConnections = max
+	}
+}
+
+// MaxMessageSize with max message size option
+func MaxMessageSize(size int64) ServerOption {
+	return func(s *ServerConfig) {
+		s.MaxMessageSize = size
+	}
+}
+
+// MaxSessionsPerKey with max sessions per metadata value, e.g. MaxSessionsPerKey("uid", 3)
+func MaxSessionsPerKey(key string, max int) ServerOption {
+	return func(s *ServerConfig) {
+		s.MaxSessionsKey = key
+		s.MaxSessionsPerKey = max
+	}
+}
+
+// readTimeout 读取超时时间，未开启 ping 时返回 0
+func (s *ServerConfig) readTimeout() time.Duration {
+	if s.PingInterval <= 0 {
+		return 0
+	}
+	if s.PongTimeout > 0 {
+		return s.PongTimeout
+	}
+	return s.PingInterval * 2
+}
@@ -43,16 +43,25 @@ import (
 var sessionPool = spool.New[*Session](func() *Session {
 	return &Session{}
 }, func(ss *Session) {
+	// 在写锁内重置，避免持有旧引用的推送写入被复用的 session
+	ss.sendMu.Lock()
+	defer ss.sendMu.Unlock()
 	ss.Id = ""
+	ss.closed = true
 	ss.baseCtx = nil
 	ss.conn = nil
 	ss.md = nil
 	ss.lastActiveTime = nil
 	ss.storeMu = nil
 	ss.codec = nil
+	ss.handshake = nil
+	ss.rooms = nil
+	ss.readTimeout = 0
+	ss.writeTimeout = 0
 })
 
-// Session websocket client session
+// Session websocket client session，session 会被回收复用，只在连接的处理函数内有效，
+// 在处理函数之外使用 Server.Session 或 Server.FindSessions 返回的 SessionRef
 type Session struct {
 	Id             string `json:"id"`
 	baseCtx        context.Context
@@ -61,23 +70,34 @@ type Session struct {
 	lastActiveTime *satomic.Value[time.Time]
 	storeMu        *sync.RWMutex
 	codec          codec.Codec
-	closeOnce      sync.Once
+	handshake      *handshake          // 握手请求
+	rooms          map[string]struct{} // 加入的房间，由 hub 维护
+	sendMu         sync.Mutex          // 保证同一时间只有一个协程写入连接
+	closed         bool                // 是否已关闭，由 sendMu 保护
+	readTimeout    time.Duration       // 读取超时时间，收到 pong 或消息后顺延
+	writeTimeout   time.Duration       // 写入超时时间
 }
 
 func acquireSession(
 	ctx context.Context,
 	codec codec.Codec,
 	conn *websocket.Conn,
+	hs *handshake,
 ) *Session {
 	ss := sessionPool.Get()
-	ss.Id = uuid.New().String()
 	ss.baseCtx = ctx
 	ss.conn = conn
 	ss.md = metadata.Pairs()
 	ss.lastActiveTime = satomic.New[time.Time]()
+	ss.lastActiveTime.Store(time.Now())
 	ss.storeMu = &sync.RWMutex{}
-	ss.closeOnce = sync.Once{}
 	ss.codec = codec
+	ss.handshake = hs
+	// 最后在写锁内设置 id，推送方通过 id 判断 session 是否被复用
+	ss.sendMu.Lock()
+	ss.Id = uuid.New().String()
+	ss.closed = false
+	ss.sendMu.Unlock()
 	return ss
 }
 
@@ -98,7 +118,44 @@ func (s *Session) Send(reply *protocol.Reply) error {
 		return err
 	}
 	//  发送bytes
-	if err = s.conn.WriteMessage(websocket.BinaryMessage, message); err != nil {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	return s.writeLocked(message)
+}
+
+// writeTo 写入已编码的消息，id 与当前 session 不一致时说明 session 已被回收复用，返回错误
+func (s *Session) writeTo(id string, message []byte) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	if s.Id != id {
+		return errors.ClientClosed("WEB_SOCKET_SESSION_CLOSED", "session: "+id+" closed")
+	}
+	return s.writeLocked(message)
+}
+
+// sendTo 编码并发送消息，id 与当前 session 不一致时返回错误
+func (s *Session) sendTo(id string, reply *protocol.Reply) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	if s.Id != id {
+		return errors.ClientClosed("WEB_SOCKET_SESSION_CLOSED", "session: "+id+" closed")
+	}
+	message, err := s.codec.Marshal(reply)
+	if err != nil {
+		return err
+	}
+	return s.writeLocked(message)
+}
+
+// writeLocked 写入消息，调用方需持有 sendMu
+func (s *Session) writeLocked(message []byte) error {
+	if s.closed {
+		return errors.ClientClosed("WEB_SOCKET_SESSION_CLOSED", "session: "+s.Id+" closed")
+	}
+	if s.writeTimeout > 0 {
+		_ = s.conn.SetWriteDeadline(time.Now().Add(s.writeTimeout))
+	}
+	if err := s.conn.WriteMessage(websocket.BinaryMessage, message); err != nil {
 		return err
 	}
 	s.lastActiveTime.Store(time.Now())
@@ -124,10 +181,12 @@ func (s *Session) Receive(request *protocol.Request) error {
 			) {
 				return errors.ClientClosed("WEB_SOCKET_CLIENT_CLOSE", err.Error())
 			}
-			return err
+			// 读取失败后连接不可再读，按客户端断开处理
+			return errors.ClientClosed("WEB_SOCKET_READ_ERROR", err.Error()).WithCause(err)
 		}
 		// 更新最新活跃时间
 		s.lastActiveTime.Store(time.Now())
+		s.extendReadDeadline()
 		// 解码数据
 		if messageType == websocket.BinaryMessage {
 			if err = s.codec.Unmarshal(message, request); err != nil {
@@ -138,6 +197,46 @@ func (s *Session) Receive(request *protocol.Request) error {
 	}
 }
 
+// LastActiveTime 最后一次收发业务消息的时间
+func (s *Session) LastActiveTime() time.Time {
+	return s.lastActiveTime.Load()
+}
+
+// extendReadDeadline 顺延读取超时时间
+func (s *Session) extendReadDeadline() {
+	if s.readTimeout > 0 {
+		_ = s.conn.SetReadDeadline(time.Now().Add(s.readTimeout))
+	}
+}
+
+// ping 发送 ping 控制帧
+func (s *Session) ping() error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	if s.closed {
+		return errors.ClientClosed("WEB_SOCKET_SESSION_CLOSED", "session: "+s.Id+" closed")
+	}
+	return s.conn.WriteControl(websocket.PingMessage, nil, s.controlDeadline())
+}
+
+// closeWith 发送关闭帧后关闭连接
+func (s *Session) closeWith(code int, text string) {
+	s.sendMu.Lock()
+	if !s.closed {
+		_ = s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), s.controlDeadline())
+	}
+	s.sendMu.Unlock()
+	_ = s.Close()
+}
+
+// controlDeadline 控制帧的写入截止时间
+func (s *Session) controlDeadline() time.Time {
+	if s.writeTimeout > 0 {
+		return time.Now().Add(s.writeTimeout)
+	}
+	return time.Now().Add(time.Second)
+}
+
 // Store 存储元数据
 func (s *Session) Store(key, value string) {
 	s.storeMu.Lock()
@@ -156,10 +255,105 @@ func (s *Session) Load(key string) string {
 	return ""
 }
 
+// Header 获取握手请求的请求头
+func (s *Session) Header(key string) string {
+	if s.handshake == nil {
+		return ""
+	}
+	if vals := s.handshake.header.Get(key); len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+// Query 获取握手请求的查询参数
+func (s *Session) Query(key string) string {
+	if s.handshake == nil {
+		return ""
+	}
+	return s.handshake.query.Get(key)
+}
+
+// Cookie 获取握手请求的 cookie
+func (s *Session) Cookie(key string) string {
+	if s.handshake == nil {
+		return ""
+	}
+	return s.handshake.cookies[key]
+}
+
 // Close 关闭
 func (s *Session) Close() error {
-	s.closeOnce.Do(func() {
-		s.conn.Close()
-	})
-	return nil
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	return s.closeLocked()
+}
+
+// closeTo 关闭 session，id 与当前 session 不一致时说明 session 已被回收复用，不做处理
+func (s *Session) closeTo(id string) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	if s.Id != id {
+		return nil
+	}
+	return s.closeLocked()
+}
+
+// closeWithTo 发送关闭帧后关闭 session，id 与当前 session 不一致时不做处理
+func (s *Session) closeWithTo(id string, code int, text string) {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	if s.Id != id || s.closed {
+		return
+	}
+	_ = s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), s.controlDeadline())
+	_ = s.closeLocked()
+}
+
+// loadFrom 加载元数据，id 与当前 session 不一致时返回空字符串
+func (s *Session) loadFrom(id string, key string) string {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	if s.Id != id {
+		return ""
+	}
+	return s.Load(key)
+}
+
+// closeLocked 关闭连接，调用方需持有 sendMu
+func (s *Session) closeLocked() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	// fasthttp 劫持的连接在处理函数返回前不会真正关闭，让读取立即超时以结束读取循环
+	_ = s.conn.SetReadDeadline(time.Now())
+	return s.conn.Close()
+}
+
+// SessionRef 在线 session 的引用，记录获取时的 session id，
+// session 断开并被复用后通过引用发送消息会返回错误，不会发送给其他连接
+type SessionRef struct {
+	id string
+	ss *Session
+}
+
+// ID session id
+func (r SessionRef) ID() string {
+	return r.id
+}
+
+// Send 发送消息
+func (r SessionRef) Send(reply *protocol.Reply) error {
+	return r.ss.sendTo(r.id, reply)
+}
+
+// Load 加载元数据，session 已断开时返回空字符串
+func (r SessionRef) Load(key string) string {
+	return r.ss.loadFrom(r.id, key)
+}
+
+// Close 关闭 session，session 已断开时不做处理
+func (r SessionRef) Close() error {
+	return r.ss.closeTo(r.id)
 }
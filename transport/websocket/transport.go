@@ -49,7 +49,11 @@ type Transport struct {
 	reply     *protocol.Reply
 }
 
+// RemoteAddr returns the remote address, client transport returns nil.
 func (t *Transport) RemoteAddr() net.Addr {
+	if t.ss == nil {
+		return nil
+	}
 	return t.ss.conn.RemoteAddr()
 }
 
@@ -78,6 +82,21 @@ func (t *Transport) ReplyHeader() transport.Header {
 	return headerCarrier(t.reply.Metadata)
 }
 
+// Session returns the client session.
+func (t *Transport) Session() *Session {
+	return t.ss
+}
+
+// Cookie returns the handshake request cookie.
+func (t *Transport) Cookie(key string) string {
+	return t.ss.Cookie(key)
+}
+
+// Query returns the handshake request query value.
+func (t *Transport) Query(key string) string {
+	return t.ss.Query(key)
+}
+
 var _ transport.Header = (*headerCarrier)(nil)
 
 type headerCarrier map[string]string
@@ -106,7 +125,7 @@ func (h headerCarrier) Add(key string, value string) {
 }
 
 func (h headerCarrier) Keys() []string {
-	keys := make([]string, len(h))
+	keys := make([]string, 0, len(h))
 	for key := range h {
 		keys = append(keys, key)
 	}